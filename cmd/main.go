@@ -3,11 +3,15 @@ package main
 import (
 	"fmt"
 	"log"
+	"mini-rag-go/internal/chunker"
 	"mini-rag-go/internal/config"
+	"mini-rag-go/internal/loader"
 	"mini-rag-go/internal/models"
 	"mini-rag-go/internal/ollama"
+	"mini-rag-go/internal/openai"
 	rag2 "mini-rag-go/internal/rag"
 	"mini-rag-go/internal/store"
+	"mini-rag-go/internal/tokenizer"
 	"mini-rag-go/internal/vector"
 	"os"
 	"strings"
@@ -21,28 +25,110 @@ func main() {
 	cfg := config.Global
 	fmt.Println("🎯 文档问答RAG系统")
 	fmt.Println(strings.Repeat("=", 50))
-	fmt.Printf("模式: %s | 模型: %s\n", cfg.LLM.Mode, cfg.LLM.Model)
+	model := cfg.LLM.Model
+	if cfg.LLM.Mode == "api" {
+		model = cfg.LLM.APIModel
+	}
+	fmt.Printf("模式: %s | 模型: %s\n", cfg.LLM.Mode, model)
 	fmt.Println(strings.Repeat("=", 50))
 	//2.检查参数
-	if len(os.Args) < 3 {
+	if len(os.Args) < 2 {
 		printUsage()
 		return
 	}
 	command := os.Args[1]
-	query := strings.Join(os.Args[2:], " ")
-	if command != "docs" {
-		fmt.Println("❌ 未知命令，请使用 'docs'")
+	if command != "docs" && command != "sync" {
+		fmt.Println("❌ 未知命令，请使用 'docs' 或 'sync'")
+		printUsage()
+		return
+	}
+	if command == "docs" && len(os.Args) < 3 {
 		printUsage()
 		return
 	}
+	query := strings.Join(os.Args[2:], " ")
 	// 3.初始化组件
 	fmt.Println("🔄 初始化系统组件...")
 	//创建嵌入器
-	embedder := vector.NewSimpleEmbedder(300)
+	embedder, err := newEmbedder(cfg)
+	if err != nil {
+		log.Fatalf("❌ 嵌入器配置错误: %v", err)
+	}
 	//创建向量存储
 	vectorStore := store.NewVectorStore(embedder)
+	vectorStore.SetQuestionWeight(cfg.App.FAQQuestionWeight)
+	if cfg.App.ContextHeader {
+		if err := vectorStore.SetHeaderTemplate(cfg.App.ContextHeaderFormat); err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+	}
 	//创建检索器
 	retriever := rag2.NewRetriever(vectorStore, cfg.App.ChunkSize, cfg.App.ChunkOverlap)
+	ingest := store.IngestOptions{
+		Workers:   cfg.Embedding.Workers,
+		BatchSize: cfg.Embedding.BatchSize,
+	}
+	retriever.SetIngestOptions(ingest)
+	vectorStore.SetMismatchPolicy(cfg.Embedding.Mismatch, ingest)
+	retriever.SetWalkOptions(loader.WalkOptions{
+		Include:        cfg.App.IncludeGlobs,
+		Exclude:        cfg.App.ExcludeGlobs,
+		FollowSymlinks: cfg.App.FollowSymlinks,
+	})
+	//按配置的字段映射加载CSV/JSONL记录
+	records := loader.NewRecordLoader(loader.RecordMapping{
+		ContentFields:  cfg.App.RecordContentFields,
+		MetadataFields: cfg.App.RecordMetadataFields,
+		IDField:        cfg.App.RecordIDField,
+	})
+	for _, ext := range []string{".csv", ".jsonl", ".ndjson"} {
+		retriever.Loaders().Register(ext, records)
+	}
+	//压缩包解压限制，防止压缩炸弹
+	archives := loader.NewArchiveLoader(retriever.Loaders(), cfg.App.ArchiveMaxBytes, cfg.App.ArchiveMaxEntries)
+	for _, ext := range []string{".zip", ".tar", ".tar.gz", ".tgz"} {
+		retriever.Loaders().Register(ext, archives)
+	}
+	//分词器：配置了词表时使用BPE，否则按字符估算
+	tok := newTokenizer(cfg.App.TokenizerVocab)
+	chunkOptions := chunker.Options{
+		ChunkSize:       cfg.App.ChunkSize,
+		ChunkOverlap:    cfg.App.ChunkOverlap,
+		WindowSentences: cfg.App.WindowSentences,
+		WindowOverlap:   cfg.App.WindowOverlap,
+		Embedder:        embedder,
+		Percentile:      cfg.App.SemanticPercentile,
+	}
+	if cfg.App.ChunkUnit == "tokens" {
+		chunkOptions.Tokenizer = tok
+	}
+	//按文件类型选择分块策略
+	selector, err := chunker.NewSelector(cfg.App.ChunkStrategy, cfg.App.ChunkStrategies, chunkOptions)
+	if err != nil {
+		log.Fatalf("❌ 分块配置错误: %v", err)
+	}
+	if cfg.App.ParentChunkSize > 0 {
+		//父子分块：按类型选择的策略切出父块，父块内再按句子切出用于检索的小块
+		parentOptions := chunkOptions
+		parentOptions.ChunkSize = cfg.App.ParentChunkSize
+		parentOptions.ChunkOverlap = 0
+		parents, err := chunker.NewSelector(cfg.App.ChunkStrategy, cfg.App.ChunkStrategies, parentOptions)
+		if err != nil {
+			log.Fatalf("❌ 分块配置错误: %v", err)
+		}
+		children, _ := chunker.New(chunker.StrategySentence, chunkOptions)
+		retriever.SetChunker(chunker.NewParentChildChunker(parents, children))
+	} else {
+		retriever.SetChunker(selector)
+	}
+	if command == "sync" {
+		fmt.Println("🔄 增量更新向量存储...")
+		if err := retriever.SyncVectorStore(cfg.App.DocsPath, cfg.App.VectorStorePath); err != nil {
+			log.Fatalf("❌ 增量更新失败: %v", err)
+		}
+		printCacheStats(embedder)
+		return
+	}
 	//4.检查或构建向量存储
 	vectorStorePath := cfg.App.VectorStorePath
 	if _, err := os.Stat(vectorStorePath); os.IsNotExist(err) {
@@ -50,6 +136,7 @@ func main() {
 		if err := retriever.BuildVectorStore(cfg.App.DocsPath, vectorStorePath); err != nil {
 			log.Fatalf("❌ 构建向量存储失败: %v", err)
 		}
+		printCacheStats(embedder)
 	} else {
 		fmt.Println("📖 加载现有向量存储...")
 		if err := vectorStore.Load(vectorStorePath); err != nil {
@@ -60,7 +147,7 @@ func main() {
 	// 5.处理查询
 	fmt.Printf("\n❓ 问题: %s\n", query)
 	fmt.Println("🔍 检索相关文档...")
-	searchResults, err := retriever.Retrieve(query, cfg.App.TopK)
+	searchResults, err := retriever.RetrieveWithFilter(query, cfg.App.TopK, cfg.App.SearchFilter)
 	if err != nil {
 		log.Fatalf("❌ 检索失败: %v", err)
 	}
@@ -72,18 +159,17 @@ func main() {
 
 	//6.生成回答
 	var answer string
-	if cfg.LLM.Mode == "local" {
-		//检查 Ollama服务
-		fmt.Println("🧠 检查Ollama服务...")
-		ollamaClient := ollama.NewClient(cfg.LLM.BaseURL, cfg.LLM.Model)
-
-		if err := ollamaClient.CheckHealth(); err != nil {
-			fmt.Printf("⚠️  Ollama服务不可用: %v\n", err)
+	if backend, name := newBackend(cfg.LLM); backend != nil {
+		//检查生成服务
+		fmt.Printf("🧠 检查%s服务...\n", name)
+		if err := backend.CheckHealth(); err != nil {
+			fmt.Printf("⚠️  %s服务不可用: %v\n", name, err)
 			fmt.Println("🔄 切换到降级模式...")
 			answer = generateFallbackAnswer(query, searchResults)
 		} else {
-			fmt.Println("✅ Ollama服务正常，生成回答...")
-			generator := rag2.NewGenerator(ollamaClient)
+			fmt.Printf("✅ %s服务正常，生成回答...\n", name)
+			generator := rag2.NewGenerator(backend)
+			generator.SetPromptBudget(tok, cfg.App.PromptTokenBudget)
 			answer, err = generator.GenerateAnswer(query, searchResults)
 			if err != nil {
 				fmt.Printf("⚠️  LLM生成失败: %v\n", err)
@@ -106,23 +192,83 @@ func main() {
 	if len(searchResults) > 0 {
 		fmt.Println("\n📚 参考来源:")
 		for i, result := range searchResults {
-			content := result.Document.Content
+			content := result.Text()
 			if len(content) > 100 {
 				content = content[:100] + "..."
 			}
-			fmt.Printf("%d. [%s] (相似度: %.2f)\n   %s\n", i+1, result.Document.Filename, result.Score, content)
+			fmt.Printf("%d. [%s] (相似度: %.2f)\n   %s\n", i+1, result.Document.SourceLabel(), result.Score, content)
+			if url := result.Document.Metadata["url"]; url != "" {
+				fmt.Printf("   🔗 %s\n", url)
+			}
 		}
 	}
 	fmt.Println(strings.Repeat("=", 50))
 }
 
+// newBackend 按模式创建回答生成后端，不是 local 或 api 模式时返回 nil
+func newBackend(cfg config.LLMConfig) (rag2.Backend, string) {
+	switch cfg.Mode {
+	case "local":
+		return ollama.NewClient(cfg.BaseURL, cfg.Model), "Ollama"
+	case "api":
+		return openai.NewChatClient(cfg.APIBaseURL, cfg.APIKey, cfg.APIModel), "API"
+	}
+	return nil, ""
+}
+
+// newEmbedder 按配置创建嵌入器，配置了缓存目录时加上磁盘缓存
+func newEmbedder(cfg *config.Config) (vector.Embedder, error) {
+	e := cfg.Embedding
+	var embedder vector.Embedder
+	switch e.Provider {
+	case "", "simple":
+		embedder = vector.NewSimpleEmbedder(e.Dimension)
+	case "ollama":
+		embedder = vector.NewOllamaEmbedder(e.BaseURL, e.Model, e.BatchSize)
+	case "api":
+		embedder = openai.NewEmbedder(cfg.LLM.APIBaseURL, cfg.LLM.APIKey, e.Model, e.BatchSize)
+	default:
+		return nil, fmt.Errorf("未知的嵌入器：%s", e.Provider)
+	}
+	if e.CacheDir == "" {
+		return embedder, nil
+	}
+	cached, err := vector.NewCachedEmbedder(embedder, e.CacheDir, e.CacheMax)
+	if err != nil {
+		fmt.Printf("⚠️  嵌入缓存不可用，不使用缓存: %v\n", err)
+		return embedder, nil
+	}
+	return cached, nil
+}
+
+// printCacheStats 打印嵌入缓存的命中情况
+func printCacheStats(embedder vector.Embedder) {
+	if cached, ok := embedder.(*vector.CachedEmbedder); ok {
+		hits, misses := cached.Stats()
+		fmt.Printf("💾 嵌入缓存：命中 %d，未命中 %d\n", hits, misses)
+	}
+}
+
+// newTokenizer 加载BPE词表，未配置或加载失败时使用估算分词器
+func newTokenizer(vocabPath string) tokenizer.Tokenizer {
+	if vocabPath == "" {
+		return tokenizer.NewEstimateTokenizer()
+	}
+	bpe, err := tokenizer.LoadBPE(vocabPath)
+	if err != nil {
+		fmt.Printf("⚠️  加载分词器词表失败，改用估算: %v\n", err)
+		return tokenizer.NewEstimateTokenizer()
+	}
+	return bpe
+}
+
 // generateFallbackAnswer 生成降级回答
 func generateFallbackAnswer(query string, results []models.SearchResult) string {
 	var answer strings.Builder
 	answer.WriteString("根据文档内容：\n\n")
 	for i, result := range results {
 		//简单提取相关信息
-		content := extractRelevantInfo(result.Document.Content, query)
+		content := extractRelevantInfo(result.Text(), query)
 		if content != "" {
 			answer.WriteString(fmt.Sprintf("%d,%s\n\n", i+1, content))
 		}
@@ -171,6 +317,7 @@ func extractRelevantInfo(content, query string) string {
 func printUsage() {
 	fmt.Println("使用方法:")
 	fmt.Println("  go run . docs \"你的问题\"")
+	fmt.Println("  go run . sync              增量更新向量存储")
 	fmt.Println()
 	fmt.Println("示例:")
 	fmt.Println("  export LLM_MODE=local")
@@ -178,8 +325,40 @@ func printUsage() {
 	fmt.Println("  go run . docs \"退款流程是怎样的？\"")
 	fmt.Println()
 	fmt.Println("环境变量:")
-	fmt.Println("  LLM_MODE          本地模式: local (默认)")
+	fmt.Println("  LLM_MODE          本地模式: local (默认)，兼容OpenAI的服务: api")
 	fmt.Println("  OLLAMA_MODEL      Ollama模型名称")
 	fmt.Println("  OLLAMA_BASE_URL   Ollama服务地址")
+	fmt.Println("  API_BASE_URL      兼容OpenAI的服务地址，如 http://localhost:8000/v1（vLLM、LM Studio、llama.cpp server）")
+	fmt.Println("  API_KEY           API密钥")
+	fmt.Println("  API_MODEL         api 模式使用的聊天模型")
+	fmt.Println("  EMBEDDER          嵌入器: simple（默认，字符n-gram）、ollama 或 api")
+	fmt.Println("  EMBEDDING_MODEL   嵌入模型，如 nomic-embed-text、bge-m3")
+	fmt.Println("  EMBEDDING_BASE_URL    嵌入服务地址，默认同 OLLAMA_BASE_URL")
+	fmt.Println("  EMBEDDING_BATCH_SIZE  每次请求嵌入的文本数")
+	fmt.Println("  EMBEDDING_WORKERS     构建向量存储时并发生成嵌入的协程数")
+	fmt.Println("  EMBEDDER_MISMATCH     向量存储与当前嵌入器不一致时: refuse（默认，拒绝加载）或 reembed（重新生成嵌入）")
+	fmt.Println("  EMBEDDING_CACHE_DIR   嵌入缓存目录，设置后未变化的文档块不再重新生成嵌入")
+	fmt.Println("  EMBEDDING_CACHE_MB    嵌入缓存的大小上限（MB）")
 	fmt.Println("  DOCS_PATH         文档目录路径")
+	fmt.Println("  INCLUDE_GLOBS     包含的文件模式，逗号分隔，如 **/*.txt,*.pdf")
+	fmt.Println("  EXCLUDE_GLOBS     排除的文件模式，逗号分隔，如 drafts/**")
+	fmt.Println("  FOLLOW_SYMLINKS   是否跟随符号链接: true/false")
+	fmt.Println("  RECORD_CONTENT_FIELDS   CSV/JSONL 中作为正文的字段，如 question,answer")
+	fmt.Println("  RECORD_METADATA_FIELDS  CSV/JSONL 中写入元数据的字段，如 category,updated_at")
+	fmt.Println("  RECORD_ID_FIELD         CSV/JSONL 中作为稳定ID的字段")
+	fmt.Println("  ARCHIVE_MAX_MB          压缩包解压后的总大小上限（MB）")
+	fmt.Println("  ARCHIVE_MAX_ENTRIES     压缩包的文件数上限")
+	fmt.Println("  CHUNK_UNIT              分块大小的单位: runes（默认）或 tokens")
+	fmt.Println("  TOKENIZER_VOCAB         BPE词表文件（tiktoken格式），未设置时按字符估算token数")
+	fmt.Println("  PROMPT_TOKEN_BUDGET     提示词的token预算，0 表示不限制")
+	fmt.Println("  PARENT_CHUNK_SIZE       父块大小，大于0时检索小块、返回所属的父块")
+	fmt.Println("  CHUNK_STRATEGY          默认分块策略: sentence、fixed、recursive、window、records、faq、semantic、code")
+	fmt.Println("  CHUNK_STRATEGIES        按文件类型指定分块策略，如 markdown=recursive,pdf=fixed")
+	fmt.Println("  CHUNK_WINDOW_SENTENCES  window 策略每块的句子数")
+	fmt.Println("  CHUNK_WINDOW_OVERLAP    window 策略相邻块重叠的句子数")
+	fmt.Println("  CHUNK_SEMANTIC_PERCENTILE  semantic 策略的断点百分位（0~100），越大切分越多")
+	fmt.Println("  FAQ_QUESTION_WEIGHT     问答对中问题文本相似度的权重（0~1）")
+	fmt.Println("  CONTEXT_HEADER          生成嵌入前为文档块加上文件名、标题、章节等上下文: true/false")
+	fmt.Println("  CONTEXT_HEADER_TEMPLATE 上下文标题模板（Go template），可用 .Filename .Title .Section .Page .Sheet .Metadata")
+	fmt.Println("  SEARCH_FILTER           按元数据过滤检索结果，如 from=support@example.com,thread_id=abc（from 为发件人邮箱地址）")
 }
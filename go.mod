@@ -3,6 +3,8 @@ module mini-rag-go
 go 1.25
 
 require (
-	github.com/ledongthuc/pdf v0.0.0-20250511090121-5959a4027728 // indirect
-	github.com/sashabaranov/go-openai v1.41.2 // indirect
+	github.com/ledongthuc/pdf v0.0.0-20250511090121-5959a4027728
+	github.com/sashabaranov/go-openai v1.41.2
+	golang.org/x/net v0.50.0
+	golang.org/x/text v0.34.0
 )
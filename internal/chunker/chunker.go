@@ -0,0 +1,261 @@
+package chunker
+
+import (
+	"fmt"
+	"mini-rag-go/internal/models"
+	"mini-rag-go/internal/tokenizer"
+	"mini-rag-go/internal/vector"
+	"path/filepath"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Chunker 文档分块器接口
+type Chunker interface {
+	Chunk(doc models.Document) []models.DocumentChunk
+}
+
+// Options 分块参数
+type Options struct {
+	ChunkSize       int                 // 块大小，默认按字符数计
+	ChunkOverlap    int                 // 相邻块的重叠，单位同 ChunkSize
+	Tokenizer       tokenizer.Tokenizer // 设置后 sentence、recursive、records、semantic、code 策略按token数计算大小
+	WindowSentences int                 // 句子窗口策略中每块的句子数
+	WindowOverlap   int                 // 句子窗口策略中相邻块重叠的句子数
+	Embedder        vector.Embedder     // 语义分块策略使用的嵌入器
+	Percentile      float64             // 语义分块的断点百分位，相邻句子相似度低于该百分位时切分
+}
+
+// 内置的分块策略名称
+const (
+	StrategySentence  = "sentence"
+	StrategyFixed     = "fixed"
+	StrategyRecursive = "recursive"
+	StrategyWindow    = "window"
+	StrategyRecords   = "records"
+	StrategyFAQ       = "faq"
+	StrategySemantic  = "semantic"
+	StrategyCode      = "code"
+)
+
+// New 按策略名称创建分块器
+func New(strategy string, opts Options) (Chunker, error) {
+	switch strings.ToLower(strategy) {
+	case "", StrategySentence:
+		c := NewSentenceChunker(opts.ChunkSize, opts.ChunkOverlap)
+		c.tokenizer = opts.Tokenizer
+		return c, nil
+	case StrategyFixed:
+		return NewFixedChunker(opts.ChunkSize, opts.ChunkOverlap), nil
+	case StrategyRecursive:
+		c := NewRecursiveChunker(opts.ChunkSize, opts.ChunkOverlap)
+		c.tokenizer = opts.Tokenizer
+		return c, nil
+	case StrategyWindow:
+		return NewWindowChunker(opts.WindowSentences, opts.WindowOverlap), nil
+	case StrategyRecords:
+		c := NewRecordsChunker(opts.ChunkSize)
+		c.tokenizer = opts.Tokenizer
+		return c, nil
+	case StrategySemantic:
+		if opts.Embedder == nil {
+			return nil, fmt.Errorf("semantic 分块策略需要嵌入器")
+		}
+		c := NewSemanticChunker(opts.Embedder, opts.Percentile, opts.ChunkSize)
+		c.tokenizer = opts.Tokenizer
+		return c, nil
+	case StrategyCode:
+		c := NewCodeChunker(opts.ChunkSize)
+		c.tokenizer = opts.Tokenizer
+		return c, nil
+	case StrategyFAQ:
+		fallback, _ := New(StrategySentence, opts)
+		return NewFAQChunker(fallback), nil
+	}
+	return nil, fmt.Errorf("未知的分块策略：%s", strategy)
+}
+
+// Selector 按文件类型选择分块器，类型取文档元数据中的 "type"，其次是文件扩展名；
+// 没有为该类型指定策略时，问答对结构的文档使用 FAQ 分块器。
+// 由 NewSelector 创建时，各策略都会保留文档中的表格，见 TableChunker
+type Selector struct {
+	Default Chunker
+	ByType  map[string]Chunker
+	FAQ     Chunker
+}
+
+// NewSelector 创建分块器选择器，strategies 为文件类型到策略名称的映射，如 {"markdown": "recursive"}
+func NewSelector(defaultStrategy string, strategies map[string]string, opts Options) (*Selector, error) {
+	def, err := New(defaultStrategy, opts)
+	if err != nil {
+		return nil, err
+	}
+	faq, _ := New(StrategyFAQ, opts)
+	s := &Selector{Default: withTables(def, opts), ByType: make(map[string]Chunker), FAQ: faq}
+	//表格类文档默认按行记录分块，可被配置覆盖
+	records, _ := New(StrategyRecords, opts)
+	s.ByType["xlsx"] = withTables(records, opts)
+	//源代码按声明切分，不做表格识别
+	code, _ := New(StrategyCode, opts)
+	s.ByType["code"] = code
+	for fileType, strategy := range strategies {
+		c, err := New(strategy, opts)
+		if err != nil {
+			return nil, err
+		}
+		s.ByType[strings.TrimPrefix(strings.ToLower(fileType), ".")] = withTables(c, opts)
+	}
+	return s, nil
+}
+
+// withTables 为分块器加上表格识别
+func withTables(c Chunker, opts Options) Chunker {
+	t := NewTableChunker(c, opts.ChunkSize)
+	t.tokenizer = opts.Tokenizer
+	return t
+}
+
+// Chunk 使用匹配的分块器分割文档
+func (s *Selector) Chunk(doc models.Document) []models.DocumentChunk {
+	return s.For(doc).Chunk(doc)
+}
+
+// For 返回文档对应的分块器
+func (s *Selector) For(doc models.Document) Chunker {
+	if c, ok := s.ByType[doc.Metadata["type"]]; ok {
+		return c
+	}
+	ext := strings.TrimPrefix(strings.ToLower(filepath.Ext(doc.Filename)), ".")
+	if c, ok := s.ByType[ext]; ok {
+		return c
+	}
+	if s.FAQ != nil && IsFAQ(doc.Content) {
+		return s.FAQ
+	}
+	return s.Default
+}
+
+// span 原文中的一段区间，按字符（rune）计
+type span struct {
+	start int
+	end   int
+}
+
+// newChunk 创建文档块
+func newChunk(doc models.Document, index int, content string, sp, byteSpan span) models.DocumentChunk {
+	return models.DocumentChunk{
+		Document: models.Document{
+			ID:       fmt.Sprintf("%s_chunk_%d", doc.ID, index),
+			Content:  content,
+			Filename: doc.Filename,
+			Metadata: doc.Metadata,
+		},
+		ChunkIndex: index,
+		StartPos:   sp.start,
+		EndPos:     sp.end,
+		StartByte:  byteSpan.start,
+		EndByte:    byteSpan.end,
+	}
+}
+
+// spansToChunks 将区间转换为文档块，去掉两端空白，跳过空块；
+// 块内容就是文档内容对应区间的子串，字符和字节位置都可以直接回指文档内容
+func spansToChunks(doc models.Document, runes []rune, spans []span) []models.DocumentChunk {
+	offsets := byteOffsets(runes)
+	var chunks []models.DocumentChunk
+	for _, sp := range spans {
+		sp = trimSpan(runes, sp)
+		if sp.start >= sp.end {
+			continue
+		}
+		chunks = append(chunks, newChunk(doc, len(chunks), string(runes[sp.start:sp.end]), sp,
+			span{offsets[sp.start], offsets[sp.end]}))
+	}
+	return chunks
+}
+
+// byteOffsets 计算每个字符在UTF-8编码中的字节位置，最后一项为总字节数
+func byteOffsets(runes []rune) []int {
+	offsets := make([]int, len(runes)+1)
+	for i, r := range runes {
+		offsets[i+1] = offsets[i] + utf8.RuneLen(r)
+	}
+	return offsets
+}
+
+// trimSpan 去掉区间两端的空白字符
+func trimSpan(runes []rune, sp span) span {
+	for sp.start < sp.end && unicode.IsSpace(runes[sp.start]) {
+		sp.start++
+	}
+	for sp.end > sp.start && unicode.IsSpace(runes[sp.end-1]) {
+		sp.end--
+	}
+	return sp
+}
+
+// sizer 计算区间大小：默认按字符数，设置分词器后按token数
+type sizer struct {
+	runes     []rune
+	tokenizer tokenizer.Tokenizer
+}
+
+// size 返回区间大小
+func (s sizer) size(sp span) int {
+	if s.tokenizer == nil {
+		return sp.end - sp.start
+	}
+	return s.tokenizer.Count(string(s.runes[sp.start:sp.end]))
+}
+
+// fit 将区间切成大小都不超过 limit 的连续片段
+func (s sizer) fit(sp span, limit int) []span {
+	if s.tokenizer == nil {
+		return fixedSpans(sp, limit, 0)
+	}
+	var spans []span
+	for start := sp.start; start < sp.end; {
+		//二分查找能放下的最长区间，至少包含一个字符
+		lo, hi := start+1, sp.end
+		for lo < hi {
+			mid := (lo + hi + 1) / 2
+			if s.size(span{start, mid}) <= limit {
+				lo = mid
+			} else {
+				hi = mid - 1
+			}
+		}
+		spans = append(spans, span{start, lo})
+		start = lo
+	}
+	return spans
+}
+
+// mergeSpans 将连续的小区间贪心合并为不超过 size 的块，相邻块之间保留不超过 overlap 的重叠
+func mergeSpans(pieces []span, size, overlap int, sz sizer) []span {
+	//区间首尾相接，块的大小取各片段大小之和
+	prefix := make([]int, len(pieces)+1)
+	for i, piece := range pieces {
+		prefix[i+1] = prefix[i] + sz.size(piece)
+	}
+	var merged []span
+	first := 0
+	for first < len(pieces) {
+		last := first
+		for last+1 < len(pieces) && prefix[last+2]-prefix[first] <= size {
+			last++
+		}
+		merged = append(merged, span{pieces[first].start, pieces[last].end})
+		if last == len(pieces)-1 {
+			break
+		}
+		//从末尾往回找，重叠部分不超过 overlap
+		next := last + 1
+		for next-1 > first && prefix[last+1]-prefix[next-1] <= overlap {
+			next--
+		}
+		first = next
+	}
+	return merged
+}
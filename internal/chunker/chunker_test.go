@@ -0,0 +1,178 @@
+package chunker
+
+import (
+	"fmt"
+	"mini-rag-go/internal/models"
+	"mini-rag-go/internal/vector"
+	"slices"
+	"testing"
+)
+
+// testDoc 创建测试文档
+func testDoc(content string, metadata map[string]string) models.Document {
+	if metadata == nil {
+		metadata = map[string]string{}
+	}
+	return models.Document{ID: "doc", Content: content, Filename: "doc.txt", Metadata: metadata}
+}
+
+// checkSpans 检查每个块的内容都是文档中字符和字节位置对应的子串
+func checkSpans(t *testing.T, doc models.Document, chunks []models.DocumentChunk) {
+	t.Helper()
+	runes := []rune(doc.Content)
+	for i, chunk := range chunks {
+		if chunk.StartPos < 0 || chunk.EndPos > len(runes) || chunk.StartPos >= chunk.EndPos {
+			t.Errorf("chunk %d has invalid rune span [%d, %d)", i, chunk.StartPos, chunk.EndPos)
+			continue
+		}
+		if got := string(runes[chunk.StartPos:chunk.EndPos]); got != chunk.Content {
+			t.Errorf("chunk %d rune span [%d, %d) = %q, content %q", i, chunk.StartPos, chunk.EndPos, got, chunk.Content)
+		}
+		if got := doc.Content[chunk.StartByte:chunk.EndByte]; got != chunk.Content {
+			t.Errorf("chunk %d byte span [%d, %d) = %q, content %q", i, chunk.StartByte, chunk.EndByte, got, chunk.Content)
+		}
+	}
+}
+
+// texts 返回各块显示的文本
+func texts(chunks []models.DocumentChunk) []string {
+	var result []string
+	for _, chunk := range chunks {
+		result = append(result, chunk.Text())
+	}
+	return result
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		strategy string
+		opts     Options
+		want     string
+		wantErr  bool
+	}{
+		{strategy: "", want: "*chunker.SentenceChunker"},
+		{strategy: "Sentence", want: "*chunker.SentenceChunker"},
+		{strategy: StrategyFixed, want: "*chunker.FixedChunker"},
+		{strategy: StrategyRecursive, want: "*chunker.RecursiveChunker"},
+		{strategy: StrategyWindow, want: "*chunker.WindowChunker"},
+		{strategy: StrategyRecords, want: "*chunker.RecordsChunker"},
+		{strategy: StrategyFAQ, want: "*chunker.FAQChunker"},
+		{strategy: StrategyCode, want: "*chunker.CodeChunker"},
+		{strategy: StrategySemantic, opts: Options{Embedder: vector.NewSimpleEmbedder(8)}, want: "*chunker.SemanticChunker"},
+		{strategy: StrategySemantic, wantErr: true},
+		{strategy: "paragraph", wantErr: true},
+	}
+	for _, tt := range tests {
+		c, err := New(tt.strategy, tt.opts)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("New(%q) succeeded, want error", tt.strategy)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("New(%q) error = %v", tt.strategy, err)
+			continue
+		}
+		if got := fmt.Sprintf("%T", c); got != tt.want {
+			t.Errorf("New(%q) = %s, want %s", tt.strategy, got, tt.want)
+		}
+	}
+}
+
+func TestStrategies(t *testing.T) {
+	tests := []struct {
+		strategy string
+		opts     Options
+		text     string
+		want     []string
+	}{
+		{
+			strategy: StrategyFixed,
+			opts:     Options{ChunkSize: 4, ChunkOverlap: 1},
+			text:     "abcdefghij",
+			want:     []string{"abcd", "defg", "ghij"},
+		},
+		{
+			strategy: StrategySentence,
+			opts:     Options{ChunkSize: 10},
+			text:     "退款七天内申请。运费买家承担。发票随包裹寄出。",
+			want:     []string{"退款七天内申请。", "运费买家承担。", "发票随包裹寄出。"},
+		},
+		{
+			strategy: StrategySentence,
+			opts:     Options{ChunkSize: 100},
+			text:     "退款七天内申请。运费买家承担。",
+			want:     []string{"退款七天内申请。运费买家承担。"},
+		},
+		{
+			strategy: StrategyRecursive,
+			opts:     Options{ChunkSize: 10},
+			text:     "第一段内容。\n\n第二段内容。",
+			want:     []string{"第一段内容。", "第二段内容。"},
+		},
+		{
+			strategy: StrategyWindow,
+			opts:     Options{WindowSentences: 2, WindowOverlap: 1},
+			text:     "第一句。第二句。第三句。",
+			want:     []string{"第一句。第二句。", "第二句。第三句。"},
+		},
+		{
+			strategy: StrategyRecords,
+			opts:     Options{ChunkSize: 20},
+			text:     "a: 1\nb: 2\n\nc: 3\nd: 4\n\ne: 5",
+			want:     []string{"a: 1\nb: 2\n\nc: 3\nd: 4", "e: 5"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.strategy, func(t *testing.T) {
+			c, err := New(tt.strategy, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			doc := testDoc(tt.text, nil)
+			chunks := c.Chunk(doc)
+			if got := texts(chunks); !slices.Equal(got, tt.want) {
+				t.Fatalf("Chunk() = %q, want %q", got, tt.want)
+			}
+			checkSpans(t, doc, chunks)
+		})
+	}
+}
+
+func TestSelectorFor(t *testing.T) {
+	s, err := NewSelector(StrategySentence, map[string]string{".md": "recursive", "pdf": "fixed"}, Options{ChunkSize: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name     string
+		filename string
+		docType  string
+		content  string
+		want     string
+	}{
+		{name: "按文档类型", filename: "notes", docType: "pdf", content: "正文", want: "*chunker.FixedChunker"},
+		{name: "按扩展名", filename: "guide.MD", content: "正文", want: "*chunker.RecursiveChunker"},
+		{name: "表格文件按记录", filename: "orders.xlsx", docType: "xlsx", content: "正文", want: "*chunker.RecordsChunker"},
+		{name: "源代码", filename: "main.go", docType: "code", content: "package main", want: "*chunker.CodeChunker"},
+		{name: "问答对", filename: "faq.txt", docType: "text", content: "Q: 怎么退款？\nA: 七天内申请。\n\nQ: 运费谁出？\nA: 买家承担。", want: "*chunker.FAQChunker"},
+		{name: "默认", filename: "a.txt", docType: "text", content: "正文", want: "*chunker.SentenceChunker"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := models.Document{Filename: tt.filename, Content: tt.content, Metadata: map[string]string{"type": tt.docType}}
+			c := s.For(doc)
+			//除源代码和问答对外都包了一层表格识别
+			if table, ok := c.(*TableChunker); ok {
+				c = table.inner
+			}
+			if got := fmt.Sprintf("%T", c); got != tt.want {
+				t.Errorf("For() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+	if _, err := NewSelector(StrategySentence, map[string]string{"md": "paragraph"}, Options{}); err == nil {
+		t.Errorf("NewSelector() with an unknown strategy succeeded")
+	}
+}
@@ -0,0 +1,299 @@
+package chunker
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"mini-rag-go/internal/models"
+	"mini-rag-go/internal/tokenizer"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// codeSymbolRe 从代码块的首行识别函数、类等符号名
+var codeSymbolRe = regexp.MustCompile(`^\s*(?:(?:export|public|private|protected|static|async|pub)\s+)*` +
+	`(?:function|def|class|func|fn|sub|struct|interface|impl|module|CREATE\s+(?:TABLE|VIEW|FUNCTION))\s+([A-Za-z_][\w.]*)` +
+	`|^\s*([A-Za-z_][\w-]*)\s*\(\)\s*\{`)
+
+// CodeChunker 源代码分块器：Go 代码用 go/parser 按顶层函数、类型等声明切分，
+// 其他语言在括号层级为零的空行处切分。块的元数据 "kind" 为 "code"，
+// 并包括符号名 "symbol" 和行号范围 "start_line"、"end_line"
+type CodeChunker struct {
+	chunkSize int
+	tokenizer tokenizer.Tokenizer
+}
+
+// NewCodeChunker 创建源代码分块器
+func NewCodeChunker(chunkSize int) *CodeChunker {
+	return &CodeChunker{chunkSize: chunkSize}
+}
+
+// codeUnit 一个不可再分的代码单元，如一个函数
+type codeUnit struct {
+	span
+	symbol string
+}
+
+// Chunk 分割文档：小的相邻单元合并为一块，超过块大小的单元按行切开
+func (c *CodeChunker) Chunk(doc models.Document) []models.DocumentChunk {
+	runes := []rune(doc.Content)
+	var units []codeUnit
+	if doc.Metadata["language"] == "go" {
+		units = goUnits(doc.Content, runes)
+	}
+	if units == nil {
+		units = blockUnits(runes)
+	}
+
+	sz := sizer{runes: runes, tokenizer: c.tokenizer}
+	offsets := byteOffsets(runes)
+	var chunks []models.DocumentChunk
+	for _, group := range c.groupUnits(units, sz) {
+		for _, sp := range c.fitLines(runes, group.span, sz) {
+			sp = trimSpan(runes, sp)
+			if sp.start >= sp.end {
+				continue
+			}
+			chunk := newChunk(doc, len(chunks), string(runes[sp.start:sp.end]), sp,
+				span{offsets[sp.start], offsets[sp.end]})
+			chunk.Metadata = copyMetadata(doc.Metadata)
+			chunk.Metadata["kind"] = "code"
+			if group.symbol != "" {
+				chunk.Metadata["symbol"] = group.symbol
+			}
+			chunk.Metadata["start_line"] = strconv.Itoa(lineNumber(runes, sp.start))
+			chunk.Metadata["end_line"] = strconv.Itoa(lineNumber(runes, sp.end-1))
+			chunks = append(chunks, chunk)
+		}
+	}
+	return chunks
+}
+
+// groupUnits 将相邻的小单元合并到块大小以内，符号名用逗号连接
+func (c *CodeChunker) groupUnits(units []codeUnit, sz sizer) []codeUnit {
+	var groups []codeUnit
+	for _, unit := range units {
+		if n := len(groups); n > 0 && sz.size(span{groups[n-1].start, unit.end}) <= c.chunkSize {
+			last := &groups[n-1]
+			last.end = unit.end
+			if unit.symbol != "" {
+				if last.symbol != "" {
+					last.symbol += ", "
+				}
+				last.symbol += unit.symbol
+			}
+			continue
+		}
+		groups = append(groups, unit)
+	}
+	return groups
+}
+
+// fitLines 超过块大小的单元依次在空行、语句边界、行尾处切分，再合并到块大小以内；
+// 只剩右括号的尾部并入前一块，避免出现只有 "}" 的块
+func (c *CodeChunker) fitLines(runes []rune, sp span, sz sizer) []span {
+	if sz.size(sp) <= c.chunkSize {
+		return []span{sp}
+	}
+	spans := mergeSpans(c.split(sz, sp, 0), c.chunkSize, 0, sz)
+	merged := spans[:1]
+	for _, next := range spans[1:] {
+		if closingOnly(runes, next) {
+			merged[len(merged)-1].end = next.end
+			continue
+		}
+		merged = append(merged, next)
+	}
+	return merged
+}
+
+// codeLevels 超大单元的各级切分方式：空行 → 括号层级不超过1的行尾（函数体内的语句之间）→ 行尾
+var codeLevels = []func(line string, depth int) bool{
+	func(line string, depth int) bool { return strings.TrimSpace(line) == "" },
+	func(line string, depth int) bool { return depth <= 1 },
+	func(line string, depth int) bool { return true },
+}
+
+// split 将区间切成不超过块大小的片段
+func (c *CodeChunker) split(sz sizer, sp span, level int) []span {
+	if sz.size(sp) <= c.chunkSize {
+		return []span{sp}
+	}
+	if level >= len(codeLevels) {
+		return sz.fit(sp, c.chunkSize)
+	}
+	var pieces []span
+	for _, piece := range splitCodeLines(sz.runes, sp, codeLevels[level]) {
+		pieces = append(pieces, c.split(sz, piece, level+1)...)
+	}
+	return pieces
+}
+
+// splitCodeLines 在满足条件的行之后切分，depth 为行尾相对区间开头的括号层级
+func splitCodeLines(runes []rune, sp span, boundary func(line string, depth int) bool) []span {
+	depth := 0
+	lineStart := sp.start
+	return splitAfter(runes, sp, func(i int) bool {
+		switch runes[i] {
+		case '{', '(', '[':
+			depth++
+		case '}', ')', ']':
+			if depth > 0 {
+				depth--
+			}
+		case '\n':
+			line := string(runes[lineStart:i])
+			lineStart = i + 1
+			return boundary(line, depth)
+		}
+		return false
+	})
+}
+
+// closingOnly 判断区间是否只有右括号、分号等收尾符号
+func closingOnly(runes []rune, sp span) bool {
+	for _, r := range runes[sp.start:sp.end] {
+		if !strings.ContainsRune("})];, \t\r\n", r) {
+			return false
+		}
+	}
+	return true
+}
+
+// goUnits 用 go/parser 解析 Go 源码，每个顶层声明（连同其文档注释）为一个单元；解析失败时返回 nil
+func goUnits(src string, runes []rune) []codeUnit {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		return nil
+	}
+	//go/token 的位置是字节偏移，换算为字符位置
+	runeIndex := make(map[int]int, len(runes)+1)
+	for i, offset := range byteOffsets(runes) {
+		runeIndex[offset] = i
+	}
+	pos := func(p token.Pos) int { return runeIndex[fset.Position(p).Offset] }
+
+	units := []codeUnit{{span: span{0, 0}, symbol: "package " + file.Name.Name}}
+	for _, decl := range file.Decls {
+		start := decl.Pos()
+		var symbol string
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if d.Doc != nil {
+				start = d.Doc.Pos()
+			}
+			symbol = goFuncName(d)
+		case *ast.GenDecl:
+			if d.Doc != nil {
+				start = d.Doc.Pos()
+			}
+			symbol = goGenDeclName(d)
+		}
+		//上一个单元延伸到本声明之前，声明之间的注释归入后一个声明
+		units[len(units)-1].end = pos(start)
+		units = append(units, codeUnit{span: span{pos(start), pos(decl.End())}, symbol: symbol})
+	}
+	units[len(units)-1].end = len(runes)
+	return units
+}
+
+// goFuncName 返回函数名，方法带上接收者类型，如 "(*VectorStore).Search"
+func goFuncName(d *ast.FuncDecl) string {
+	if d.Recv == nil || len(d.Recv.List) == 0 {
+		return d.Name.Name
+	}
+	return fmt.Sprintf("(%s).%s", goTypeName(d.Recv.List[0].Type), d.Name.Name)
+}
+
+// goTypeName 返回接收者类型名
+func goTypeName(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return "*" + goTypeName(t.X)
+	case *ast.Ident:
+		return t.Name
+	case *ast.IndexExpr:
+		return goTypeName(t.X)
+	case *ast.IndexListExpr:
+		return goTypeName(t.X)
+	}
+	return "?"
+}
+
+// goGenDeclName 返回 type、var、const 声明的名称，import 声明返回 "import"
+func goGenDeclName(d *ast.GenDecl) string {
+	var names []string
+	for _, spec := range d.Specs {
+		switch s := spec.(type) {
+		case *ast.TypeSpec:
+			names = append(names, s.Name.Name)
+		case *ast.ValueSpec:
+			for _, name := range s.Names {
+				names = append(names, name.Name)
+			}
+		case *ast.ImportSpec:
+			return "import"
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+// blockUnits 在括号层级为零的空行处切分代码，首行能识别出符号时记录符号名
+func blockUnits(runes []rune) []codeUnit {
+	var units []codeUnit
+	depth := 0
+	start := 0
+	lineStart := 0
+	for i := 0; i <= len(runes); i++ {
+		if i < len(runes) && runes[i] != '\n' {
+			switch runes[i] {
+			case '{', '(', '[':
+				depth++
+			case '}', ')', ']':
+				if depth > 0 {
+					depth--
+				}
+			}
+			continue
+		}
+		//到达行尾：空行且不在括号内时结束当前单元
+		blank := strings.TrimSpace(string(runes[lineStart:i])) == ""
+		if i == len(runes) || blank && depth == 0 && i > start {
+			if sp := (span{start, i}); trimSpan(runes, sp).start < sp.end {
+				units = append(units, codeUnit{span: sp, symbol: blockSymbol(runes, sp)})
+			} else if len(units) > 0 {
+				units[len(units)-1].end = i
+			}
+			start = i
+		}
+		lineStart = i + 1
+	}
+	return units
+}
+
+// blockSymbol 在代码块中找第一个函数或类的定义
+func blockSymbol(runes []rune, sp span) string {
+	for _, line := range strings.Split(string(runes[sp.start:sp.end]), "\n") {
+		if m := codeSymbolRe.FindStringSubmatch(line); m != nil {
+			if m[1] != "" {
+				return m[1]
+			}
+			return m[2]
+		}
+	}
+	return ""
+}
+
+// lineNumber 返回字符位置所在的行号（从1开始）
+func lineNumber(runes []rune, pos int) int {
+	line := 1
+	for _, r := range runes[:pos] {
+		if r == '\n' {
+			line++
+		}
+	}
+	return line
+}
@@ -0,0 +1,102 @@
+package chunker
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestCodeChunker(t *testing.T) {
+	goSrc := "package refund\n\n// Days 退款期限\nconst Days = 7\n\nfunc Apply(id string) error {\n\treturn nil\n}\n"
+	tests := []struct {
+		name        string
+		text        string
+		language    string
+		chunkSize   int
+		want        []string
+		wantSymbols []string
+		wantLines   []string
+	}{
+		{
+			name:        "Go声明合并为一块",
+			text:        goSrc,
+			language:    "go",
+			chunkSize:   500,
+			want:        []string{strings.TrimSpace(goSrc)},
+			wantSymbols: []string{"package refund, Days, Apply"},
+			wantLines:   []string{"1-8"},
+		},
+		{
+			name:        "Go按声明切分",
+			text:        goSrc,
+			language:    "go",
+			chunkSize:   46,
+			want:        []string{"package refund\n\n// Days 退款期限\nconst Days = 7", "func Apply(id string) error {\n\treturn nil\n}"},
+			wantSymbols: []string{"package refund, Days", "Apply"},
+			wantLines:   []string{"1-4", "6-8"},
+		},
+		{
+			name:        "超大函数不切出只有右括号的块",
+			text:        "func Apply(id string) error {\n\tlog.Println(id)\n\treturn nil\n}",
+			language:    "go",
+			chunkSize:   40,
+			want:        []string{"func Apply(id string) error {", "log.Println(id)\n\treturn nil\n}"},
+			wantSymbols: []string{"Apply", "Apply"},
+			wantLines:   []string{"1-1", "2-4"},
+		},
+		{
+			name:      "超大函数在空行处切分",
+			text:      "function apply(order) {\n  check(order);\n  log(order);\n\n  refund(order);\n  notify(order);\n}",
+			language:  "javascript",
+			chunkSize: 60,
+			want: []string{
+				"function apply(order) {\n  check(order);\n  log(order);",
+				"refund(order);\n  notify(order);\n}",
+			},
+			wantSymbols: []string{"apply", "apply"},
+			wantLines:   []string{"1-3", "5-7"},
+		},
+		{
+			name:      "超大函数在语句之间切分",
+			text:      "function apply(order) {\n  if (order.paid) {\n    refund(order);\n  }\n  notify(order);\n}",
+			language:  "javascript",
+			chunkSize: 70,
+			want: []string{
+				"function apply(order) {\n  if (order.paid) {\n    refund(order);\n  }",
+				"notify(order);\n}",
+			},
+			wantSymbols: []string{"apply", "apply"},
+			wantLines:   []string{"1-4", "5-6"},
+		},
+		{
+			name:        "其他语言按空行切分",
+			text:        "function a() {\n  return 1;\n}\n\nfunction b() {\n  return 2;\n}",
+			language:    "javascript",
+			chunkSize:   30,
+			want:        []string{"function a() {\n  return 1;\n}", "function b() {\n  return 2;\n}"},
+			wantSymbols: []string{"a", "b"},
+			wantLines:   []string{"1-3", "5-7"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := testDoc(tt.text, map[string]string{"language": tt.language})
+			chunks := NewCodeChunker(tt.chunkSize).Chunk(doc)
+			if got := texts(chunks); !slices.Equal(got, tt.want) {
+				t.Fatalf("Chunk() = %q, want %q", got, tt.want)
+			}
+			checkSpans(t, doc, chunks)
+			for i, chunk := range chunks {
+				if got := chunk.Metadata["symbol"]; got != tt.wantSymbols[i] {
+					t.Errorf("chunk %d symbol = %q, want %q", i, got, tt.wantSymbols[i])
+				}
+				if got := chunk.Metadata["start_line"] + "-" + chunk.Metadata["end_line"]; got != tt.wantLines[i] {
+					t.Errorf("chunk %d lines = %s, want %s", i, got, tt.wantLines[i])
+				}
+				if chunk.Metadata["kind"] != "code" {
+					t.Errorf("chunk %d kind = %q, want code", i, chunk.Metadata["kind"])
+				}
+			}
+		})
+	}
+}
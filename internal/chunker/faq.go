@@ -0,0 +1,101 @@
+package chunker
+
+import (
+	"mini-rag-go/internal/models"
+	"regexp"
+	"strings"
+)
+
+var (
+	faqQuestionRe = regexp.MustCompile(`^\s*(?:Q\d*|问题?|提问)\s*[:：]\s*`)
+	faqAnswerRe   = regexp.MustCompile(`^\s*(?:A\d*|答案?|回答)\s*[:：]\s*`)
+)
+
+// FAQChunker 问答对分块器，每个问题和它的答案组成一个块，问题文本写入元数据 "question"
+type FAQChunker struct {
+	fallback Chunker
+}
+
+// NewFAQChunker 创建问答对分块器，不是问答结构的文档交给 fallback 分割
+func NewFAQChunker(fallback Chunker) *FAQChunker {
+	return &FAQChunker{fallback: fallback}
+}
+
+// IsFAQ 判断文本是否为 Q:/A: 问答对结构：至少两个问题，且大部分问题后面跟着答案
+func IsFAQ(text string) bool {
+	questions, answered := 0, 0
+	pending := false
+	for _, line := range strings.Split(text, "\n") {
+		switch {
+		case faqQuestionRe.MatchString(line):
+			questions++
+			pending = true
+		case faqAnswerRe.MatchString(line) && pending:
+			answered++
+			pending = false
+		}
+	}
+	return questions >= 2 && answered*2 > questions
+}
+
+// Chunk 分割文档，问答对不论长短都不会被拆开或合并；第一个问题之前的内容单独成块
+func (c *FAQChunker) Chunk(doc models.Document) []models.DocumentChunk {
+	if !IsFAQ(doc.Content) {
+		return c.fallback.Chunk(doc)
+	}
+	runes := []rune(doc.Content)
+	//按行扫描，记录每个问题行的起始位置
+	var starts []int
+	var questions []string
+	pos := 0
+	inQuestion := false
+	for _, line := range strings.SplitAfter(doc.Content, "\n") {
+		text := strings.TrimSpace(line)
+		switch {
+		case faqQuestionRe.MatchString(line):
+			starts = append(starts, pos)
+			questions = append(questions, strings.TrimSpace(faqQuestionRe.ReplaceAllString(text, "")))
+			inQuestion = true
+		case faqAnswerRe.MatchString(line) || text == "":
+			inQuestion = false
+		case inQuestion:
+			//问题跨多行
+			questions[len(questions)-1] += " " + text
+		}
+		pos += len([]rune(line))
+	}
+
+	spans := []span{{0, starts[0]}}
+	for i, start := range starts {
+		end := len(runes)
+		if i+1 < len(starts) {
+			end = starts[i+1]
+		}
+		spans = append(spans, span{start, end})
+	}
+	offsets := byteOffsets(runes)
+	var chunks []models.DocumentChunk
+	for i, sp := range spans {
+		sp = trimSpan(runes, sp)
+		if sp.start >= sp.end {
+			continue
+		}
+		chunk := newChunk(doc, len(chunks), string(runes[sp.start:sp.end]), sp,
+			span{offsets[sp.start], offsets[sp.end]})
+		if i > 0 {
+			chunk.Metadata = copyMetadata(doc.Metadata)
+			chunk.Metadata["question"] = questions[i-1]
+		}
+		chunks = append(chunks, chunk)
+	}
+	return chunks
+}
+
+// copyMetadata 复制元数据，供需要写入块级元数据的分块器使用
+func copyMetadata(metadata map[string]string) map[string]string {
+	copied := make(map[string]string, len(metadata)+1)
+	for key, value := range metadata {
+		copied[key] = value
+	}
+	return copied
+}
@@ -0,0 +1,76 @@
+package chunker
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestIsFAQ(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want bool
+	}{
+		{name: "Q/A", text: "Q: 怎么退款？\nA: 七天内申请。\nQ: 运费谁出？\nA: 买家承担。", want: true},
+		{name: "中文标记", text: "问：怎么退款？\n答：七天内申请。\n\n问题：运费谁出？\n回答：买家承担。", want: true},
+		{name: "编号", text: "Q1: 怎么退款？\nA1: 七天内申请。\nQ2: 运费谁出？\nA2: 买家承担。", want: true},
+		{name: "只有一个问题", text: "Q: 怎么退款？\nA: 七天内申请。", want: false},
+		{name: "大部分问题没有答案", text: "Q: 一？\nQ: 二？\nQ: 三？\nA: 答。", want: false},
+		{name: "普通文本", text: "退款需在七天内申请。\n运费由买家承担。", want: false},
+	}
+	for _, tt := range tests {
+		if got := IsFAQ(tt.text); got != tt.want {
+			t.Errorf("IsFAQ(%s) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFAQChunker(t *testing.T) {
+	tests := []struct {
+		name          string
+		text          string
+		want          []string
+		wantQuestions []string
+	}{
+		{
+			name: "问答对各成一块",
+			text: "常见问题\n\nQ: 怎么退款？\nA: 七天内申请。\n\nQ: 运费谁出？\nA: 买家承担，\n质量问题除外。",
+			want: []string{
+				"常见问题",
+				"Q: 怎么退款？\nA: 七天内申请。",
+				"Q: 运费谁出？\nA: 买家承担，\n质量问题除外。",
+			},
+			wantQuestions: []string{"", "怎么退款？", "运费谁出？"},
+		},
+		{
+			name:          "问题跨多行",
+			text:          "问：退款时\n运费怎么算？\n答：按原路退回。\n问：多久到账？\n答：三天。",
+			want:          []string{"问：退款时\n运费怎么算？\n答：按原路退回。", "问：多久到账？\n答：三天。"},
+			wantQuestions: []string{"退款时 运费怎么算？", "多久到账？"},
+		},
+		{
+			name:          "不是问答结构时交给后备分块器",
+			text:          "退款需在七天内申请。运费由买家承担。",
+			want:          []string{"退款需在七天内申请。", "运费由买家承担。"},
+			wantQuestions: []string{"", ""},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := testDoc(tt.text, map[string]string{"type": "text"})
+			chunks := NewFAQChunker(NewSentenceChunker(12, 0)).Chunk(doc)
+			if got := texts(chunks); !slices.Equal(got, tt.want) {
+				t.Fatalf("Chunk() = %q, want %q", got, tt.want)
+			}
+			checkSpans(t, doc, chunks)
+			for i, chunk := range chunks {
+				if got := chunk.Metadata["question"]; got != tt.wantQuestions[i] {
+					t.Errorf("chunk %d question = %q, want %q", i, got, tt.wantQuestions[i])
+				}
+			}
+			if _, ok := doc.Metadata["question"]; ok {
+				t.Errorf("document metadata was modified")
+			}
+		})
+	}
+}
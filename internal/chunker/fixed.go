@@ -0,0 +1,41 @@
+package chunker
+
+import "mini-rag-go/internal/models"
+
+// FixedChunker 按固定字符数分块
+type FixedChunker struct {
+	chunkSize    int
+	chunkOverlap int
+}
+
+// NewFixedChunker 创建固定大小分块器
+func NewFixedChunker(chunkSize, chunkOverlap int) *FixedChunker {
+	if chunkOverlap < 0 || chunkOverlap >= chunkSize {
+		chunkOverlap = 0
+	}
+	return &FixedChunker{chunkSize: chunkSize, chunkOverlap: chunkOverlap}
+}
+
+// Chunk 分割文档
+func (c *FixedChunker) Chunk(doc models.Document) []models.DocumentChunk {
+	runes := []rune(doc.Content)
+	return spansToChunks(doc, runes, fixedSpans(span{0, len(runes)}, c.chunkSize, c.chunkOverlap))
+}
+
+// fixedSpans 将区间按固定大小切分
+func fixedSpans(sp span, size, overlap int) []span {
+	if size <= 0 {
+		return []span{sp}
+	}
+	var spans []span
+	step := size - overlap
+	for start := sp.start; start < sp.end; start += step {
+		end := start + size
+		if end >= sp.end {
+			spans = append(spans, span{start, sp.end})
+			break
+		}
+		spans = append(spans, span{start, end})
+	}
+	return spans
+}
@@ -0,0 +1,53 @@
+package chunker
+
+import (
+	"fmt"
+	"mini-rag-go/internal/models"
+)
+
+// Hierarchical 能生成父子两级文档块的分块器：子块用于检索，命中后返回所属的父块作为上下文
+type Hierarchical interface {
+	Chunker
+	Split(doc models.Document) (parents, children []models.DocumentChunk)
+}
+
+// ParentChildChunker 先用 parent 把文档切成较大的父块，再用 child 把每个父块切成小的子块
+type ParentChildChunker struct {
+	parent Chunker
+	child  Chunker
+}
+
+// NewParentChildChunker 创建父子分块器
+func NewParentChildChunker(parent, child Chunker) *ParentChildChunker {
+	return &ParentChildChunker{parent: parent, child: child}
+}
+
+// Chunk 只返回子块
+func (c *ParentChildChunker) Chunk(doc models.Document) []models.DocumentChunk {
+	_, children := c.Split(doc)
+	return children
+}
+
+// Split 分割文档，子块的 ParentID 指向所属父块，位置都相对于源文档
+func (c *ParentChildChunker) Split(doc models.Document) (parents, children []models.DocumentChunk) {
+	parents = c.parent.Chunk(doc)
+	for _, parent := range parents {
+		//表格和代码不再切小，整块作为唯一的子块
+		if kind := parent.Metadata["kind"]; kind == "table" || kind == "code" {
+			child := parent
+			child.ID = parent.ID + "_child_0"
+			child.ParentID = parent.ID
+			child.ChunkIndex = len(children)
+			children = append(children, child)
+			continue
+		}
+		for _, child := range c.child.Chunk(parent.Document) {
+			child = shiftChunk(child, parent.StartPos, parent.StartByte)
+			child.ID = fmt.Sprintf("%s_child_%d", parent.ID, child.ChunkIndex)
+			child.ParentID = parent.ID
+			child.ChunkIndex = len(children)
+			children = append(children, child)
+		}
+	}
+	return parents, children
+}
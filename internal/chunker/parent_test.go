@@ -0,0 +1,83 @@
+package chunker
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParentChildChunkerSplit(t *testing.T) {
+	tests := []struct {
+		name         string
+		text         string
+		parent       Chunker
+		wantParents  int
+		wantChildren []int // 每个父块的子块数
+	}{
+		{
+			name:         "句子",
+			text:         "退款需在七天内申请。运费由买家承担。发票随包裹寄出。换货不收运费。",
+			parent:       NewSentenceChunker(20, 0),
+			wantParents:  2,
+			wantChildren: []int{2, 2},
+		},
+		{
+			name:         "文档小于父块",
+			text:         "退款需在七天内申请。运费由买家承担。",
+			parent:       NewSentenceChunker(100, 0),
+			wantParents:  1,
+			wantChildren: []int{2},
+		},
+		{
+			name:         "表格不再切小",
+			text:         "| 商品 | 期限 |\n| --- | --- |\n| 衣服 | 7天 |\n| 鞋子 | 15天 |",
+			parent:       NewTableChunker(NewSentenceChunker(100, 0), 100),
+			wantParents:  1,
+			wantChildren: []int{1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := testDoc(tt.text, nil)
+			c := NewParentChildChunker(tt.parent, NewSentenceChunker(12, 0))
+			parents, children := c.Split(doc)
+			if len(parents) != tt.wantParents {
+				t.Fatalf("Split() = %d parents, want %d", len(parents), tt.wantParents)
+			}
+			checkSpans(t, doc, parents)
+			checkSpans(t, doc, children)
+
+			counts := make(map[string]int)
+			ids := make(map[string]bool)
+			for i, child := range children {
+				if ids[child.ID] {
+					t.Errorf("duplicate child ID %s", child.ID)
+				}
+				ids[child.ID] = true
+				if child.ChunkIndex != i {
+					t.Errorf("child %d index = %d", i, child.ChunkIndex)
+				}
+				counts[child.ParentID]++
+			}
+			for i, parent := range parents {
+				if got := counts[parent.ID]; got != tt.wantChildren[i] {
+					t.Errorf("parent %d has %d children, want %d", i, got, tt.wantChildren[i])
+				}
+			}
+			//子块位于所属父块之内
+			byID := make(map[string]int)
+			for i, parent := range parents {
+				byID[parent.ID] = i
+			}
+			for _, child := range children {
+				parent := parents[byID[child.ParentID]]
+				if child.StartPos < parent.StartPos || child.EndPos > parent.EndPos || !strings.Contains(parent.Content, child.Content) {
+					t.Errorf("child %s [%d, %d) is outside parent %s [%d, %d)",
+						child.ID, child.StartPos, child.EndPos, parent.ID, parent.StartPos, parent.EndPos)
+				}
+			}
+			if got := c.Chunk(doc); len(got) != len(children) {
+				t.Errorf("Chunk() = %d chunks, want the %d children", len(got), len(children))
+			}
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package chunker
+
+import (
+	"mini-rag-go/internal/models"
+	"mini-rag-go/internal/tokenizer"
+	"strings"
+)
+
+// RecordsChunker 按行记录分割表格类文档，记录之间以空行分隔，单条记录不会被拆开
+type RecordsChunker struct {
+	chunkSize int
+	tokenizer tokenizer.Tokenizer
+}
+
+// NewRecordsChunker 创建按记录分块的分块器
+func NewRecordsChunker(chunkSize int) *RecordsChunker {
+	return &RecordsChunker{chunkSize: chunkSize}
+}
+
+// Chunk 分割文档
+func (c *RecordsChunker) Chunk(doc models.Document) []models.DocumentChunk {
+	runes := []rune(doc.Content)
+	var records []span
+	start := 0
+	for _, record := range strings.Split(doc.Content, "\n\n") {
+		n := len([]rune(record))
+		records = append(records, span{start, start + n})
+		start += n + 2
+	}
+	sz := sizer{runes: runes, tokenizer: c.tokenizer}
+	return spansToChunks(doc, runes, mergeSpans(records, c.chunkSize, 0, sz))
+}
@@ -0,0 +1,52 @@
+package chunker
+
+import (
+	"mini-rag-go/internal/models"
+	"mini-rag-go/internal/tokenizer"
+)
+
+// RecursiveChunker 递归分隔符分块器：依次按段落、行、句子切分，仍然过长时按字符切分
+type RecursiveChunker struct {
+	chunkSize    int
+	chunkOverlap int
+	tokenizer    tokenizer.Tokenizer
+}
+
+// NewRecursiveChunker 创建递归分块器
+func NewRecursiveChunker(chunkSize, chunkOverlap int) *RecursiveChunker {
+	return &RecursiveChunker{chunkSize: chunkSize, chunkOverlap: chunkOverlap}
+}
+
+// Chunk 分割文档
+func (c *RecursiveChunker) Chunk(doc models.Document) []models.DocumentChunk {
+	runes := []rune(doc.Content)
+	sz := sizer{runes: runes, tokenizer: c.tokenizer}
+	pieces := c.split(sz, span{0, len(runes)}, 0)
+	return spansToChunks(doc, runes, mergeSpans(pieces, c.chunkSize, c.chunkOverlap, sz))
+}
+
+// recursiveLevels 各级切分方式：段落 → 行 → 句子
+var recursiveLevels = []func(runes []rune, sp span) []span{
+	func(runes []rune, sp span) []span {
+		return splitAfter(runes, sp, func(i int) bool { return runes[i] == '\n' && i > 0 && runes[i-1] == '\n' })
+	},
+	func(runes []rune, sp span) []span {
+		return splitAfter(runes, sp, func(i int) bool { return runes[i] == '\n' })
+	},
+	sentenceSpansIn,
+}
+
+// split 将区间切成不超过块大小的片段
+func (c *RecursiveChunker) split(sz sizer, sp span, level int) []span {
+	if sz.size(sp) <= c.chunkSize {
+		return []span{sp}
+	}
+	if level >= len(recursiveLevels) {
+		return sz.fit(sp, c.chunkSize)
+	}
+	var pieces []span
+	for _, piece := range recursiveLevels[level](sz.runes, sp) {
+		pieces = append(pieces, c.split(sz, piece, level+1)...)
+	}
+	return pieces
+}
@@ -0,0 +1,110 @@
+package chunker
+
+import (
+	"fmt"
+	"math"
+	"mini-rag-go/internal/models"
+	"mini-rag-go/internal/tokenizer"
+	"mini-rag-go/internal/utils"
+	"mini-rag-go/internal/vector"
+	"sort"
+)
+
+// DefaultSemanticPercentile 默认的断点百分位：相邻句子相似度低于第10百分位时开始新块
+const DefaultSemanticPercentile = 10
+
+// SemanticChunker 语义分块器，在相邻句子相似度明显下降（话题切换）的位置切分，块大小仍不超过 chunkSize
+type SemanticChunker struct {
+	embedder   vector.Embedder
+	percentile float64
+	chunkSize  int
+	tokenizer  tokenizer.Tokenizer
+}
+
+// NewSemanticChunker 创建语义分块器，percentile 为断点阈值所在的相似度百分位（0~100）
+func NewSemanticChunker(embedder vector.Embedder, percentile float64, chunkSize int) *SemanticChunker {
+	if percentile <= 0 || percentile >= 100 {
+		percentile = DefaultSemanticPercentile
+	}
+	return &SemanticChunker{embedder: embedder, percentile: percentile, chunkSize: chunkSize}
+}
+
+// Chunk 分割文档
+func (c *SemanticChunker) Chunk(doc models.Document) []models.DocumentChunk {
+	runes := []rune(doc.Content)
+	sz := sizer{runes: runes, tokenizer: c.tokenizer}
+	//句子区间首尾相接，只有空白的区间并入前一个句子，保证块大小计算准确
+	var sentences []span
+	for _, sentence := range sentenceSpans(runes) {
+		if blank := trimSpan(runes, sentence); blank.start == blank.end && len(sentences) > 0 {
+			sentences[len(sentences)-1].end = sentence.end
+			continue
+		}
+		sentences = append(sentences, sentence)
+	}
+	if len(sentences) < 3 {
+		return spansToChunks(doc, runes, mergeSpans(c.fitSentences(sentences, sz), c.chunkSize, 0, sz))
+	}
+
+	similarities, err := c.adjacentSimilarities(runes, sentences)
+	if err != nil {
+		fmt.Printf("警告：语义分块生成嵌入失败，改为按句子分块：%s：%v\n", doc.ID, err)
+		return spansToChunks(doc, runes, mergeSpans(c.fitSentences(sentences, sz), c.chunkSize, 0, sz))
+	}
+	threshold := percentile(similarities, c.percentile)
+
+	//在相似度低于阈值处断开，每组句子再按大小上限合并
+	var spans []span
+	first := 0
+	for i := 1; i <= len(sentences); i++ {
+		if i == len(sentences) || similarities[i-1] < threshold {
+			spans = append(spans, mergeSpans(c.fitSentences(sentences[first:i], sz), c.chunkSize, 0, sz)...)
+			first = i
+		}
+	}
+	return spansToChunks(doc, runes, spans)
+}
+
+// adjacentSimilarities 计算相邻句子的余弦相似度，所有句子一次批量生成嵌入
+func (c *SemanticChunker) adjacentSimilarities(runes []rune, sentences []span) ([]float64, error) {
+	texts := make([]string, len(sentences))
+	for i, sentence := range sentences {
+		sentence = trimSpan(runes, sentence)
+		texts[i] = string(runes[sentence.start:sentence.end])
+	}
+	vectors, err := vector.BatchEmbed(c.embedder, texts)
+	if err != nil {
+		return nil, err
+	}
+	if len(vectors) != len(texts) {
+		return nil, fmt.Errorf("嵌入数量不匹配：请求 %d 条，返回 %d 条", len(texts), len(vectors))
+	}
+	similarities := make([]float64, len(sentences)-1)
+	for i := range similarities {
+		similarities[i] = utils.CosineSimilarity(vectors[i], vectors[i+1])
+	}
+	return similarities, nil
+}
+
+// fitSentences 将超过块大小的句子切开
+func (c *SemanticChunker) fitSentences(sentences []span, sz sizer) []span {
+	var fitted []span
+	for _, sentence := range sentences {
+		if sz.size(sentence) > c.chunkSize {
+			fitted = append(fitted, sz.fit(sentence, c.chunkSize)...)
+		} else {
+			fitted = append(fitted, sentence)
+		}
+	}
+	return fitted
+}
+
+// percentile 计算第 p 百分位数（线性插值）
+func percentile(values []float64, p float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	pos := p / 100 * float64(len(sorted)-1)
+	lower := int(math.Floor(pos))
+	upper := int(math.Ceil(pos))
+	return sorted[lower] + (sorted[upper]-sorted[lower])*(pos-float64(lower))
+}
@@ -0,0 +1,113 @@
+package chunker
+
+import (
+	"errors"
+	"math"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// topicEmbedder 按关键词生成向量的嵌入器，记录批量请求次数
+type topicEmbedder struct {
+	batches int
+	fail    bool
+}
+
+func (e *topicEmbedder) Embed(text string) ([]float32, error) {
+	if e.fail {
+		return nil, errors.New("嵌入服务不可用")
+	}
+	switch {
+	case strings.Contains(text, "退款"):
+		return []float32{1, 0, 0}, nil
+	case strings.Contains(text, "运费"):
+		return []float32{0, 1, 0}, nil
+	}
+	return []float32{0, 0, 1}, nil
+}
+
+func (e *topicEmbedder) EmbedBatch(texts []string) ([][]float32, error) {
+	e.batches++
+	var vectors [][]float32
+	for _, text := range texts {
+		v, err := e.Embed(text)
+		if err != nil {
+			return nil, err
+		}
+		vectors = append(vectors, v)
+	}
+	return vectors, nil
+}
+
+func (e *topicEmbedder) Dimension() int { return 3 }
+
+func TestSemanticChunker(t *testing.T) {
+	text := "退款需在七天内申请。退款原路退回。退款三天到账。运费由买家承担。运费按重量计算。偏远地区运费加收。"
+	tests := []struct {
+		name      string
+		text      string
+		chunkSize int
+		fail      bool
+		want      []string
+	}{
+		{
+			name:      "在话题切换处切分",
+			text:      text,
+			chunkSize: 100,
+			want:      []string{"退款需在七天内申请。退款原路退回。退款三天到账。", "运费由买家承担。运费按重量计算。偏远地区运费加收。"},
+		},
+		{
+			name:      "同一话题内仍受块大小限制",
+			text:      text,
+			chunkSize: 20,
+			want:      []string{"退款需在七天内申请。退款原路退回。", "退款三天到账。", "运费由买家承担。运费按重量计算。", "偏远地区运费加收。"},
+		},
+		{
+			name:      "句子太少时按大小合并",
+			text:      "退款需在七天内申请。运费由买家承担。",
+			chunkSize: 100,
+			want:      []string{"退款需在七天内申请。运费由买家承担。"},
+		},
+		{
+			name:      "嵌入失败时按句子分块",
+			text:      text,
+			chunkSize: 40,
+			fail:      true,
+			want:      []string{"退款需在七天内申请。退款原路退回。退款三天到账。运费由买家承担。运费按重量计算。", "偏远地区运费加收。"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			embedder := &topicEmbedder{fail: tt.fail}
+			doc := testDoc(tt.text, nil)
+			chunks := NewSemanticChunker(embedder, 0, tt.chunkSize).Chunk(doc)
+			if got := texts(chunks); !slices.Equal(got, tt.want) {
+				t.Fatalf("Chunk() = %q, want %q", got, tt.want)
+			}
+			checkSpans(t, doc, chunks)
+			if embedder.batches > 1 {
+				t.Errorf("sentences embedded in %d batches, want 1", embedder.batches)
+			}
+		})
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	tests := []struct {
+		values []float64
+		p      float64
+		want   float64
+	}{
+		{values: []float64{0.5}, p: 10, want: 0.5},
+		{values: []float64{1, 0, 1, 1, 1}, p: 10, want: 0.4},
+		{values: []float64{0.1, 0.2, 0.3}, p: 50, want: 0.2},
+		{values: []float64{0.3, 0.1, 0.2, 0.4}, p: 50, want: 0.25},
+		{values: []float64{0.3, 0.1}, p: 100, want: 0.3},
+	}
+	for _, tt := range tests {
+		if got := percentile(tt.values, tt.p); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("percentile(%v, %v) = %v, want %v", tt.values, tt.p, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package chunker
+
+import (
+	"mini-rag-go/internal/models"
+	"mini-rag-go/internal/tokenizer"
+	"mini-rag-go/internal/utils"
+)
+
+// SentenceChunker 按句子贪心合并的分块器
+type SentenceChunker struct {
+	chunkSize    int
+	chunkOverlap int
+	tokenizer    tokenizer.Tokenizer
+}
+
+// NewSentenceChunker 创建按句子分块的分块器
+func NewSentenceChunker(chunkSize, chunkOverlap int) *SentenceChunker {
+	return &SentenceChunker{
+		chunkSize:    chunkSize,
+		chunkOverlap: chunkOverlap,
+	}
+}
+
+// Chunk 分割文档，相邻块之间重叠不超过 chunkOverlap 的末尾句子
+func (c *SentenceChunker) Chunk(doc models.Document) []models.DocumentChunk {
+	runes := []rune(doc.Content)
+	sz := sizer{runes: runes, tokenizer: c.tokenizer}
+	whole := span{0, len(runes)}
+	if sz.size(whole) <= c.chunkSize {
+		//文档足够小，不需要分割
+		return spansToChunks(doc, runes, []span{whole})
+	}
+	//超长的句子再切开
+	var sentences []span
+	for _, sentence := range sentenceSpans(runes) {
+		if sz.size(sentence) > c.chunkSize {
+			sentences = append(sentences, sz.fit(sentence, c.chunkSize)...)
+		} else {
+			sentences = append(sentences, sentence)
+		}
+	}
+	return spansToChunks(doc, runes, mergeSpans(sentences, c.chunkSize, c.chunkOverlap, sz))
+}
+
+// WindowChunker 句子窗口分块器，每块包含固定数量的连续句子，相邻窗口重叠若干句
+type WindowChunker struct {
+	sentences int
+	overlap   int
+}
+
+// NewWindowChunker 创建句子窗口分块器
+func NewWindowChunker(sentences, overlap int) *WindowChunker {
+	if sentences <= 0 {
+		sentences = 3
+	}
+	if overlap < 0 || overlap >= sentences {
+		overlap = 0
+	}
+	return &WindowChunker{sentences: sentences, overlap: overlap}
+}
+
+// Chunk 分割文档
+func (c *WindowChunker) Chunk(doc models.Document) []models.DocumentChunk {
+	runes := []rune(doc.Content)
+	sentences := sentenceSpans(runes)
+	if len(sentences) == 0 {
+		return nil
+	}
+	var spans []span
+	step := c.sentences - c.overlap
+	for first := 0; first < len(sentences); first += step {
+		last := first + c.sentences - 1
+		if last >= len(sentences) {
+			last = len(sentences) - 1
+		}
+		spans = append(spans, span{sentences[first].start, sentences[last].end})
+		if last == len(sentences)-1 {
+			break
+		}
+	}
+	return spansToChunks(doc, runes, spans)
+}
+
+// sentenceSpans 按句子切分区间，规则见 utils.SentenceEnds
+func sentenceSpans(runes []rune) []span {
+	return sentenceSpansIn(runes, span{0, len(runes)})
+}
+
+// sentenceSpansIn 在区间 sp 内按句子切分
+func sentenceSpansIn(runes []rune, sp span) []span {
+	var spans []span
+	start := sp.start
+	for _, end := range utils.SentenceEnds(runes[sp.start:sp.end]) {
+		spans = append(spans, span{start, sp.start + end})
+		start = sp.start + end
+	}
+	return spans
+}
+
+// splitAfter 在满足 boundary 的位置之后切分区间
+func splitAfter(runes []rune, sp span, boundary func(i int) bool) []span {
+	var pieces []span
+	start := sp.start
+	for i := sp.start; i < sp.end; i++ {
+		if boundary(i) {
+			pieces = append(pieces, span{start, i + 1})
+			start = i + 1
+		}
+	}
+	if start < sp.end {
+		pieces = append(pieces, span{start, sp.end})
+	}
+	return pieces
+}
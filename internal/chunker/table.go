@@ -0,0 +1,212 @@
+package chunker
+
+import (
+	"fmt"
+	"mini-rag-go/internal/models"
+	"mini-rag-go/internal/tokenizer"
+	"regexp"
+	"strings"
+)
+
+var (
+	tableSepRe    = regexp.MustCompile(`^\s*\|?\s*:?-{3,}:?\s*(\|\s*:?-{3,}:?\s*)*\|?\s*$`)
+	tableColumnRe = regexp.MustCompile(`\t+| {2,}`)
+)
+
+// TableChunker 保留表格的分块器：识别 Markdown 表格、竖线分隔的表格（HTML、DOCX 加载器的输出）
+// 和空格对齐的文本列，每个表格单独成块；表格过大时按行切分，每块都重复表头。
+// 表格块的元数据 "kind" 为 "table"，表格以外的内容交给 inner 分割
+type TableChunker struct {
+	inner     Chunker
+	chunkSize int
+	tokenizer tokenizer.Tokenizer
+}
+
+// NewTableChunker 创建保留表格的分块器
+func NewTableChunker(inner Chunker, chunkSize int) *TableChunker {
+	return &TableChunker{inner: inner, chunkSize: chunkSize}
+}
+
+// table 文档中的一个表格，header 和 rows 为行区间
+type table struct {
+	header []span
+	rows   []span
+}
+
+// Chunk 分割文档
+func (c *TableChunker) Chunk(doc models.Document) []models.DocumentChunk {
+	if doc.Metadata["layout"] == "rows" {
+		//表格加载器输出的行记录，每条记录都带有列名
+		return markTable(c.inner.Chunk(doc))
+	}
+	runes := []rune(doc.Content)
+	tables := findTables(runes)
+	if len(tables) == 0 {
+		return c.inner.Chunk(doc)
+	}
+
+	offsets := byteOffsets(runes)
+	sz := sizer{runes: runes, tokenizer: c.tokenizer}
+	var chunks []models.DocumentChunk
+	add := func(chunk models.DocumentChunk) {
+		chunk.ID = fmt.Sprintf("%s_chunk_%d", doc.ID, len(chunks))
+		chunk.ChunkIndex = len(chunks)
+		chunks = append(chunks, chunk)
+	}
+	start := 0
+	for _, t := range tables {
+		tableStart := t.header[0].start
+		//表格之前的普通文本
+		if text := (span{start, tableStart}); trimSpan(runes, text).start < text.end {
+			for _, chunk := range c.inner.Chunk(subDocument(doc, runes, text)) {
+				add(shiftChunk(chunk, text.start, offsets[text.start]))
+			}
+		}
+		for _, chunk := range c.tableChunks(doc, runes, offsets, sz, t) {
+			add(chunk)
+		}
+		start = t.rows[len(t.rows)-1].end
+	}
+	if text := (span{start, len(runes)}); trimSpan(runes, text).start < text.end {
+		for _, chunk := range c.inner.Chunk(subDocument(doc, runes, text)) {
+			add(shiftChunk(chunk, text.start, offsets[text.start]))
+		}
+	}
+	return chunks
+}
+
+// tableChunks 将表格转换为文档块：放得下时整表一块，否则按行切分，之后各块在 TableHeader 中重复表头；
+// 每块的内容都是原文中位置区间对应的子串
+func (c *TableChunker) tableChunks(doc models.Document, runes []rune, offsets []int, sz sizer, t table) []models.DocumentChunk {
+	headerSpan := span{t.header[0].start, t.header[len(t.header)-1].end}
+	whole := span{headerSpan.start, t.rows[len(t.rows)-1].end}
+	groups := []span{whole}
+	if sz.size(whole) > c.chunkSize {
+		headerSize := sz.size(headerSpan) + 1
+		groups = mergeSpans(t.rows, c.chunkSize-headerSize, 0, sz)
+		groups[0].start = headerSpan.start
+	}
+	header := string(runes[headerSpan.start:headerSpan.end])
+	var chunks []models.DocumentChunk
+	for i, sp := range groups {
+		chunk := newChunk(doc, 0, string(runes[sp.start:sp.end]), sp, span{offsets[sp.start], offsets[sp.end]})
+		if i > 0 {
+			chunk.TableHeader = header
+		}
+		chunk.Metadata = copyMetadata(doc.Metadata)
+		chunk.Metadata["kind"] = "table"
+		chunks = append(chunks, chunk)
+	}
+	return chunks
+}
+
+// findTables 按行扫描，找出文本中的表格
+func findTables(runes []rune) []table {
+	lines := splitAfter(runes, span{0, len(runes)}, func(i int) bool { return runes[i] == '\n' })
+	texts := make([]string, len(lines))
+	for i, line := range lines {
+		line = trimSpan(runes, line)
+		lines[i] = line
+		texts[i] = string(runes[line.start:line.end])
+	}
+
+	var tables []table
+	for i := 0; i < len(lines); {
+		if n := pipeTableLen(texts[i:]); n > 0 {
+			headerLines := 1
+			if n > 2 && tableSepRe.MatchString(texts[i+1]) {
+				headerLines = 2
+			}
+			tables = append(tables, table{header: lines[i : i+headerLines], rows: lines[i+headerLines : i+n]})
+			i += n
+			continue
+		}
+		if n := alignedTableLen(texts[i:]); n > 0 {
+			headerLines := 1
+			if n > 3 && strings.Trim(texts[i+1], "-=+ \t") == "" {
+				headerLines = 2
+			}
+			tables = append(tables, table{header: lines[i : i+headerLines], rows: lines[i+headerLines : i+n]})
+			i += n
+			continue
+		}
+		i++
+	}
+	return tables
+}
+
+// pipeTableLen 返回从第一行开始的竖线表格的行数，各行的列数相同；不是表格时返回 0
+func pipeTableLen(lines []string) int {
+	cells := pipeCells(lines[0])
+	if cells < 2 {
+		return 0
+	}
+	n := 1
+	for n < len(lines) && (pipeCells(lines[n]) == cells || tableSepRe.MatchString(lines[n]) && strings.Contains(lines[n], "|")) {
+		n++
+	}
+	if n < 2 || n == 2 && tableSepRe.MatchString(lines[1]) {
+		return 0
+	}
+	return n
+}
+
+// pipeCells 返回竖线分隔的单元格数，没有竖线时返回 0
+func pipeCells(line string) int {
+	if !strings.Contains(line, "|") {
+		return 0
+	}
+	return len(strings.Split(strings.Trim(line, "|"), "|"))
+}
+
+// alignedTableLen 返回从第一行开始的空格对齐表格的行数：至少3行，各行按两个以上空格或制表符分出相同的列数
+func alignedTableLen(lines []string) int {
+	columns := alignedCells(lines[0])
+	if columns < 2 {
+		return 0
+	}
+	n := 1
+	for n < len(lines) {
+		if alignedCells(lines[n]) != columns && !(n == 1 && lines[n] != "" && strings.Trim(lines[n], "-=+ \t") == "") {
+			break
+		}
+		n++
+	}
+	if n < 3 {
+		return 0
+	}
+	return n
+}
+
+// alignedCells 返回按两个以上空格或制表符分隔的列数
+func alignedCells(line string) int {
+	if line == "" {
+		return 0
+	}
+	return len(tableColumnRe.Split(line, -1))
+}
+
+// markTable 把文档块标记为表格
+func markTable(chunks []models.DocumentChunk) []models.DocumentChunk {
+	for i := range chunks {
+		chunks[i].Metadata = copyMetadata(chunks[i].Metadata)
+		chunks[i].Metadata["kind"] = "table"
+	}
+	return chunks
+}
+
+// subDocument 取文档的一段作为子文档，供内部分块器分割
+func subDocument(doc models.Document, runes []rune, sp span) models.Document {
+	sub := doc
+	sub.Content = string(runes[sp.start:sp.end])
+	return sub
+}
+
+// shiftChunk 将子文档中的块位置换算为源文档中的位置
+func shiftChunk(chunk models.DocumentChunk, runeOffset, byteOffset int) models.DocumentChunk {
+	chunk.StartPos += runeOffset
+	chunk.EndPos += runeOffset
+	chunk.StartByte += byteOffset
+	chunk.EndByte += byteOffset
+	return chunk
+}
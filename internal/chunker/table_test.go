@@ -0,0 +1,134 @@
+package chunker
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestFindTables(t *testing.T) {
+	tests := []struct {
+		name       string
+		text       string
+		wantTables int
+		wantHeader int
+		wantRows   int
+	}{
+		{
+			name:       "Markdown表格",
+			text:       "前言\n| 商品 | 期限 |\n| --- | --- |\n| 衣服 | 7天 |\n| 鞋 | 15天 |\n结尾",
+			wantTables: 1, wantHeader: 2, wantRows: 2,
+		},
+		{
+			name:       "竖线表格没有分隔行",
+			text:       "商品 | 期限\n衣服 | 7天\n鞋 | 15天",
+			wantTables: 1, wantHeader: 1, wantRows: 2,
+		},
+		{
+			name:       "空格对齐的表格",
+			text:       "商品    期限    运费\n衣服    7天     免费\n鞋      15天    10元",
+			wantTables: 1, wantHeader: 1, wantRows: 2,
+		},
+		{
+			name:       "对齐表格带下划线",
+			text:       "商品    期限\n------  ----\n衣服    7天\n鞋      15天",
+			wantTables: 1, wantHeader: 2, wantRows: 2,
+		},
+		{
+			name:       "只有两行的对齐文本不是表格",
+			text:       "商品    期限\n衣服    7天",
+			wantTables: 0,
+		},
+		{
+			name:       "只有表头和分隔行不是表格",
+			text:       "| a | b |\n| --- | --- |",
+			wantTables: 0,
+		},
+		{
+			name:       "普通文本",
+			text:       "退款需要在七天内申请。\n请保留发票。",
+			wantTables: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tables := findTables([]rune(tt.text))
+			if len(tables) != tt.wantTables {
+				t.Fatalf("findTables() = %d tables, want %d", len(tables), tt.wantTables)
+			}
+			if tt.wantTables == 0 {
+				return
+			}
+			if got := len(tables[0].header); got != tt.wantHeader {
+				t.Errorf("header lines = %d, want %d", got, tt.wantHeader)
+			}
+			if got := len(tables[0].rows); got != tt.wantRows {
+				t.Errorf("rows = %d, want %d", got, tt.wantRows)
+			}
+		})
+	}
+}
+
+func TestTableChunker(t *testing.T) {
+	table := "| 商品 | 期限 |\n| --- | --- |\n| 衣服 | 7天 |\n| 鞋子 | 15天 |\n| 家电 | 30天 |"
+	tests := []struct {
+		name      string
+		text      string
+		chunkSize int
+		want      []string
+		wantKinds []string
+	}{
+		{
+			name:      "整表一块",
+			text:      "退货说明。\n" + table + "\n以上为期限。",
+			chunkSize: 200,
+			want:      []string{"退货说明。", table, "以上为期限。"},
+			wantKinds: []string{"", "table", ""},
+		},
+		{
+			name:      "按行切分并重复表头",
+			text:      table,
+			chunkSize: 40,
+			want: []string{
+				"| 商品 | 期限 |\n| --- | --- |\n| 衣服 | 7天 |",
+				"| 商品 | 期限 |\n| --- | --- |\n| 鞋子 | 15天 |",
+				"| 商品 | 期限 |\n| --- | --- |\n| 家电 | 30天 |",
+			},
+			wantKinds: []string{"table", "table", "table"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := testDoc(tt.text, nil)
+			c := NewTableChunker(NewSentenceChunker(tt.chunkSize, 0), tt.chunkSize)
+			chunks := c.Chunk(doc)
+			if got := texts(chunks); !slices.Equal(got, tt.want) {
+				t.Fatalf("Chunk() = %q, want %q", got, tt.want)
+			}
+			checkSpans(t, doc, chunks)
+			for i, chunk := range chunks {
+				if got := chunk.Metadata["kind"]; got != tt.wantKinds[i] {
+					t.Errorf("chunk %d kind = %q, want %q", i, got, tt.wantKinds[i])
+				}
+				if chunk.ChunkIndex != i {
+					t.Errorf("chunk %d index = %d", i, chunk.ChunkIndex)
+				}
+			}
+		})
+	}
+}
+
+func TestTableChunkerRowsLayout(t *testing.T) {
+	doc := testDoc("商品: 衣服\n期限: 7天\n\n商品: 鞋\n期限: 15天", map[string]string{"layout": "rows"})
+	chunks := NewTableChunker(NewRecordsChunker(500), 500).Chunk(doc)
+	if len(chunks) == 0 {
+		t.Fatal("Chunk() returned no chunks")
+	}
+	for i, chunk := range chunks {
+		if chunk.Metadata["kind"] != "table" {
+			t.Errorf("chunk %d kind = %q, want table", i, chunk.Metadata["kind"])
+		}
+	}
+	if doc.Metadata["kind"] != "" {
+		t.Errorf("document metadata was modified")
+	}
+}
@@ -3,31 +3,70 @@ package config
 import (
 	"os"
 	"strconv"
+	"strings"
 )
 
 // AppConfig 应用配置
 type AppConfig struct {
-	DocsPath            string
-	VectorStorePath     string
-	ChunkSize           int
-	ChunkOverlap        int
-	TopK                int
-	SimilarityThreshold float64
+	DocsPath             string
+	VectorStorePath      string
+	ChunkSize            int
+	ChunkOverlap         int
+	ParentChunkSize      int
+	ChunkUnit            string
+	TokenizerVocab       string
+	PromptTokenBudget    int
+	ChunkStrategy        string
+	ChunkStrategies      map[string]string
+	WindowSentences      int
+	WindowOverlap        int
+	SemanticPercentile   float64
+	FAQQuestionWeight    float64
+	ContextHeader        bool
+	ContextHeaderFormat  string
+	TopK                 int
+	SimilarityThreshold  float64
+	IncludeGlobs         []string
+	ExcludeGlobs         []string
+	FollowSymlinks       bool
+	RecordContentFields  []string
+	RecordMetadataFields []string
+	RecordIDField        string
+	ArchiveMaxBytes      int64
+	ArchiveMaxEntries    int
+	SearchFilter         map[string]string
 }
 
 // LLMConfig LLM配置
 type LLMConfig struct {
-	Mode        string
+	Mode        string // local 使用 Ollama，api 使用兼容 OpenAI 的服务
 	Model       string
 	BaseURL     string
+	APIBaseURL  string
+	APIKey      string
+	APIModel    string
 	Temperature float32
 	MaxTokens   int
 }
 
+// EmbeddingConfig 嵌入模型配置
+type EmbeddingConfig struct {
+	Provider  string // simple、ollama 或 api
+	Model     string
+	BaseURL   string
+	BatchSize int
+	Workers   int    // 构建向量存储时并发生成嵌入的协程数
+	Mismatch  string // 向量存储与当前嵌入器不一致时: refuse 或 reembed
+	Dimension int    // simple 嵌入器的向量维度
+	CacheDir  string
+	CacheMax  int64
+}
+
 // Config 全局配置
 type Config struct {
-	App AppConfig
-	LLM LLMConfig
+	App       AppConfig
+	LLM       LLMConfig
+	Embedding EmbeddingConfig
 }
 
 // Global 全局配置实例
@@ -38,20 +77,55 @@ func InitConfig() {
 	//从环境变量读取 LLM模式
 	Global = &Config{
 		App: AppConfig{
-			DocsPath:            getEnv("DOCS_PATH", "docs"),
-			VectorStorePath:     getEnv("VECTOR_STORE_PATH", "internal/store/vector_store.json"),
-			ChunkSize:           getEnvAsInt("CHUNK_SIZE", 500),
-			ChunkOverlap:        getEnvAsInt("CHUNK_OVERLAP", 50),
-			TopK:                getEnvAsInt("TOP_K", 3),
-			SimilarityThreshold: getEnvAsFloat("SIMILARITY_THRESHOLD", 0.7),
+			DocsPath:             getEnv("DOCS_PATH", "docs"),
+			VectorStorePath:      getEnv("VECTOR_STORE_PATH", "internal/store/vector_store.json"),
+			ChunkSize:            getEnvAsInt("CHUNK_SIZE", 500),
+			ChunkOverlap:         getEnvAsInt("CHUNK_OVERLAP", 50),
+			ParentChunkSize:      getEnvAsInt("PARENT_CHUNK_SIZE", 0),
+			ChunkUnit:            getEnv("CHUNK_UNIT", "runes"),
+			TokenizerVocab:       getEnv("TOKENIZER_VOCAB", ""),
+			PromptTokenBudget:    getEnvAsInt("PROMPT_TOKEN_BUDGET", 0),
+			ChunkStrategy:        getEnv("CHUNK_STRATEGY", "sentence"),
+			ChunkStrategies:      getEnvAsMap("CHUNK_STRATEGIES"),
+			WindowSentences:      getEnvAsInt("CHUNK_WINDOW_SENTENCES", 3),
+			WindowOverlap:        getEnvAsInt("CHUNK_WINDOW_OVERLAP", 1),
+			SemanticPercentile:   getEnvAsFloat("CHUNK_SEMANTIC_PERCENTILE", 10),
+			FAQQuestionWeight:    getEnvAsFloat("FAQ_QUESTION_WEIGHT", 0.6),
+			ContextHeader:        getEnvAsBool("CONTEXT_HEADER", false),
+			ContextHeaderFormat:  getEnv("CONTEXT_HEADER_TEMPLATE", ""),
+			TopK:                 getEnvAsInt("TOP_K", 3),
+			SimilarityThreshold:  getEnvAsFloat("SIMILARITY_THRESHOLD", 0.7),
+			IncludeGlobs:         getEnvAsSlice("INCLUDE_GLOBS", nil),
+			ExcludeGlobs:         getEnvAsSlice("EXCLUDE_GLOBS", nil),
+			FollowSymlinks:       getEnvAsBool("FOLLOW_SYMLINKS", false),
+			RecordContentFields:  getEnvAsSlice("RECORD_CONTENT_FIELDS", nil),
+			RecordMetadataFields: getEnvAsSlice("RECORD_METADATA_FIELDS", nil),
+			RecordIDField:        getEnv("RECORD_ID_FIELD", ""),
+			ArchiveMaxBytes:      int64(getEnvAsInt("ARCHIVE_MAX_MB", 200)) << 20,
+			ArchiveMaxEntries:    getEnvAsInt("ARCHIVE_MAX_ENTRIES", 10000),
+			SearchFilter:         getEnvAsMap("SEARCH_FILTER"),
 		},
 		LLM: LLMConfig{
 			Mode:        getEnv("LLM_MODE", "local"),
 			Model:       getEnv("OLLAMA_MODEL", "qwen2.5:7b"),
 			BaseURL:     getEnv("OLLAMA_BASE_URL", "http://localhost:11434"),
+			APIBaseURL:  getEnv("API_BASE_URL", "http://localhost:8000/v1"),
+			APIKey:      getEnv("API_KEY", ""),
+			APIModel:    getEnv("API_MODEL", ""),
 			Temperature: getEnvAsFloat32("LLM_TEMPERATURE", 0.7),
 			MaxTokens:   getEnvAsInt("MAX_TOKENS", 1024),
 		},
+		Embedding: EmbeddingConfig{
+			Provider:  getEnv("EMBEDDER", "simple"),
+			Model:     getEnv("EMBEDDING_MODEL", "nomic-embed-text"),
+			BaseURL:   getEnv("EMBEDDING_BASE_URL", getEnv("OLLAMA_BASE_URL", "http://localhost:11434")),
+			BatchSize: getEnvAsInt("EMBEDDING_BATCH_SIZE", 32),
+			Workers:   getEnvAsInt("EMBEDDING_WORKERS", 4),
+			Mismatch:  getEnv("EMBEDDER_MISMATCH", "refuse"),
+			Dimension: getEnvAsInt("EMBEDDING_DIMENSION", 300),
+			CacheDir:  getEnv("EMBEDDING_CACHE_DIR", ""),
+			CacheMax:  int64(getEnvAsInt("EMBEDDING_CACHE_MB", 512)) << 20,
+		},
 	}
 	//打印配置信息
 	printConfig()
@@ -84,6 +158,42 @@ func getEnvAsInt(key string, defaultValue int) int {
 	return defaultValue
 }
 
+// getEnvAsBool 获取布尔类型环境变量
+func getEnvAsBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			return boolValue
+		}
+	}
+	return defaultValue
+}
+
+// getEnvAsSlice 获取逗号分隔的列表环境变量
+func getEnvAsSlice(key string, defaultValue []string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// getEnvAsMap 获取 "key=value,key2=value2" 形式的环境变量
+func getEnvAsMap(key string) map[string]string {
+	result := make(map[string]string)
+	for _, item := range getEnvAsSlice(key, nil) {
+		if k, v, ok := strings.Cut(item, "="); ok {
+			result[strings.TrimSpace(k)] = strings.TrimSpace(v)
+		}
+	}
+	return result
+}
+
 // 辅助函数：获取环境变量
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
@@ -96,9 +206,15 @@ func printConfig() {
 	println("=== 配置信息 ===")
 	println("文档目录:", Global.App.DocsPath)
 	println("向量存储:", Global.App.VectorStorePath)
+	println("分块策略:", Global.App.ChunkStrategy)
+	println("嵌入器:", Global.Embedding.Provider)
 	println("LLM模式:", Global.LLM.Mode)
 	println("LLM模型:", Global.LLM.Model)
-	println("Ollama地址:", Global.LLM.BaseURL)
+	if Global.LLM.Mode == "api" {
+		println("API地址:", Global.LLM.APIBaseURL)
+	} else {
+		println("Ollama地址:", Global.LLM.BaseURL)
+	}
 	println("温度:", Global.LLM.Temperature)
 	println("===============\n")
 }
@@ -0,0 +1,212 @@
+package loader
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"mini-rag-go/internal/models"
+	"path"
+	"strings"
+)
+
+const (
+	// DefaultArchiveMaxBytes 压缩包解压后的默认总大小上限
+	DefaultArchiveMaxBytes = 200 << 20
+	// DefaultArchiveMaxEntries 压缩包默认的文件数上限
+	DefaultArchiveMaxEntries = 10000
+)
+
+// Expander 可以展开为多个内部文件的加载器（如压缩包）
+type Expander interface {
+	Expand(src Source) ([]Source, error)
+}
+
+// ArchiveLoader zip / tar / tar.gz 压缩包加载器，在内存中解压，
+// 内部文件交给注册表中的加载器处理
+type ArchiveLoader struct {
+	Registry   *Registry
+	MaxBytes   int64 // 解压后的总大小上限
+	MaxEntries int   // 文件数上限
+}
+
+// NewArchiveLoader 创建压缩包加载器
+func NewArchiveLoader(registry *Registry, maxBytes int64, maxEntries int) *ArchiveLoader {
+	return &ArchiveLoader{
+		Registry:   registry,
+		MaxBytes:   maxBytes,
+		MaxEntries: maxEntries,
+	}
+}
+
+// Load 展开压缩包并加载其中支持的文件
+func (l *ArchiveLoader) Load(src Source) ([]models.Document, error) {
+	sources, err := l.Expand(src)
+	if err != nil {
+		return nil, err
+	}
+	var documents []models.Document
+	for _, inner := range sources {
+		//与检索器的处理一致，不展开嵌套的压缩包，避免每层重新计算配额
+		if innerLoader, _, ok := l.Registry.Lookup(inner.Name, inner.Data); ok {
+			if _, nested := innerLoader.(Expander); nested {
+				fmt.Printf("警告：跳过 %s：不支持嵌套的压缩包\n", inner.RelPath)
+				continue
+			}
+		}
+		docs, err := l.Registry.Load(inner)
+		if err != nil {
+			if !errors.Is(err, ErrUnsupported) {
+				fmt.Printf("警告：跳过 %s：%v\n", inner.RelPath, err)
+			}
+			continue
+		}
+		documents = append(documents, docs...)
+	}
+	return documents, nil
+}
+
+// Expand 读取压缩包中的所有普通文件，不写入磁盘
+func (l *ArchiveLoader) Expand(src Source) ([]Source, error) {
+	lower := strings.ToLower(src.Name)
+	switch {
+	case strings.HasSuffix(lower, ".zip"):
+		return l.expandZip(src)
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		gz, err := gzip.NewReader(bytes.NewReader(src.Data))
+		if err != nil {
+			return nil, fmt.Errorf("打开gzip失败：%v", err)
+		}
+		defer gz.Close()
+		return l.expandTar(src, gz)
+	case strings.HasSuffix(lower, ".tar"):
+		return l.expandTar(src, bytes.NewReader(src.Data))
+	}
+	return nil, fmt.Errorf("%w：%s", ErrUnsupported, src.Name)
+}
+
+// expandZip 展开zip
+func (l *ArchiveLoader) expandZip(src Source) ([]Source, error) {
+	zr, err := zip.NewReader(bytes.NewReader(src.Data), int64(len(src.Data)))
+	if err != nil {
+		return nil, fmt.Errorf("打开zip失败：%v", err)
+	}
+	budget := l.newBudget()
+	var sources []Source
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() || skipArchiveEntry(f.Name) {
+			continue
+		}
+		if err := budget.entry(); err != nil {
+			return nil, err
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("读取 %s 失败：%v", f.Name, err)
+		}
+		data, err := budget.read(rc)
+		rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("读取 %s 失败：%v", f.Name, err)
+		}
+		sources = append(sources, innerSource(src, f.Name, data, budget))
+	}
+	return sources, nil
+}
+
+// expandTar 展开tar
+func (l *ArchiveLoader) expandTar(src Source, r io.Reader) ([]Source, error) {
+	tr := tar.NewReader(r)
+	budget := l.newBudget()
+	var sources []Source
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("解析tar失败：%v", err)
+		}
+		if hdr.Typeflag != tar.TypeReg || skipArchiveEntry(hdr.Name) {
+			continue
+		}
+		if err := budget.entry(); err != nil {
+			return nil, err
+		}
+		data, err := budget.read(tr)
+		if err != nil {
+			return nil, fmt.Errorf("读取 %s 失败：%v", hdr.Name, err)
+		}
+		sources = append(sources, innerSource(src, hdr.Name, data, budget))
+	}
+	return sources, nil
+}
+
+// innerSource 生成压缩包内文件的来源，路径形如 "bundle.zip!docs/faq.txt"；
+// 内部文件共用压缩包剩余的解压配额，DOCX、XLSX 等本身是zip的文件解压时继续扣减
+func innerSource(archive Source, name string, data []byte, budget *archiveBudget) Source {
+	name = strings.TrimPrefix(path.Clean("/"+strings.ReplaceAll(name, "\\", "/")), "/")
+	return Source{
+		Name:    path.Base(name),
+		Path:    archive.Path + "!" + name,
+		RelPath: archive.DocID("") + "!" + name,
+		Archive: archive.DocID(""),
+		Data:    data,
+		budget:  budget,
+	}
+}
+
+// skipArchiveEntry 跳过系统生成的附属文件
+func skipArchiveEntry(name string) bool {
+	return strings.HasPrefix(name, "__MACOSX/") || path.Base(name) == ".DS_Store"
+}
+
+// archiveBudget 解压配额，防止压缩炸弹
+type archiveBudget struct {
+	remaining  int64
+	maxBytes   int64
+	entries    int
+	maxEntries int
+}
+
+// newBudget 创建解压配额
+func (l *ArchiveLoader) newBudget() *archiveBudget {
+	return newArchiveBudget(l.MaxBytes, l.MaxEntries)
+}
+
+// newArchiveBudget 创建解压配额，maxBytes、maxEntries 不大于0时不限制
+func newArchiveBudget(maxBytes int64, maxEntries int) *archiveBudget {
+	return &archiveBudget{
+		remaining:  maxBytes,
+		maxBytes:   maxBytes,
+		maxEntries: maxEntries,
+	}
+}
+
+// entry 记录一个文件，超过文件数上限时返回错误
+func (b *archiveBudget) entry() error {
+	b.entries++
+	if b.maxEntries > 0 && b.entries > b.maxEntries {
+		return fmt.Errorf("压缩包文件数超过上限 %d", b.maxEntries)
+	}
+	return nil
+}
+
+// read 读取文件内容，解压后总大小超过上限时返回错误
+func (b *archiveBudget) read(r io.Reader) ([]byte, error) {
+	if b.maxBytes <= 0 {
+		return io.ReadAll(r)
+	}
+	data, err := io.ReadAll(io.LimitReader(r, b.remaining+1))
+	if err != nil {
+		return nil, err
+	}
+	b.remaining -= int64(len(data))
+	if b.remaining < 0 {
+		return nil, fmt.Errorf("解压后大小超过上限 %d 字节", b.maxBytes)
+	}
+	return data, nil
+}
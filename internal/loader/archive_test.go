@@ -0,0 +1,82 @@
+package loader
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestArchiveLoaderLoad(t *testing.T) {
+	nested := zipFiles(t, map[string]string{"deep.txt": "嵌套文件"})
+	bundle := zipFiles(t, map[string]string{
+		"faq.txt":      "退款需要七天。",
+		"inner.zip":    string(nested),
+		"__MACOSX/x":   "ignored",
+		"docs/a.txt":   "第二个文件。",
+		"unknown.bin1": "\x00\x01",
+	})
+	registry := DefaultRegistry()
+	l := NewArchiveLoader(registry, 1<<20, 100)
+	docs, err := l.Load(Source{Name: "bundle.zip", RelPath: "bundle.zip", Data: bundle})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	var ids []string
+	for _, doc := range docs {
+		ids = append(ids, doc.ID)
+		if strings.Contains(doc.Content, "嵌套文件") {
+			t.Errorf("nested archive was expanded: %s", doc.ID)
+		}
+		if doc.Metadata["archive"] != "bundle.zip" {
+			t.Errorf("%s archive metadata = %q", doc.ID, doc.Metadata["archive"])
+		}
+	}
+	if len(docs) != 2 {
+		t.Errorf("Load() documents = %v, want faq.txt and docs/a.txt", ids)
+	}
+}
+
+func TestArchiveLimits(t *testing.T) {
+	tests := []struct {
+		name       string
+		files      map[string]string
+		maxBytes   int64
+		maxEntries int
+		want       string
+	}{
+		{
+			name:       "文件数超过上限",
+			files:      map[string]string{"a.txt": "a", "b.txt": "b", "c.txt": "c"},
+			maxEntries: 2,
+			want:       "文件数超过上限",
+		},
+		{
+			name:     "解压后大小超过上限",
+			files:    map[string]string{"a.txt": strings.Repeat("a", 600), "b.txt": strings.Repeat("b", 600)},
+			maxBytes: 1000,
+			want:     "超过上限",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := NewArchiveLoader(DefaultRegistry(), tt.maxBytes, tt.maxEntries)
+			_, err := l.Expand(Source{Name: "a.zip", Data: zipFiles(t, tt.files)})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Expand() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestArchiveBudgetSharedWithOfficeFiles(t *testing.T) {
+	//压缩包内的DOCX解压正文时扣减压缩包剩余的配额
+	docx := zipFiles(t, map[string]string{"word/document.xml": strings.Repeat("a", 4096)})
+	bundle := zipFiles(t, map[string]string{"report.docx": string(docx)})
+	l := NewArchiveLoader(DefaultRegistry(), int64(len(docx))+1024, 10)
+	sources, err := l.Expand(Source{Name: "bundle.zip", Data: bundle})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadDOCX(sources[0]); err == nil || !strings.Contains(err.Error(), "超过上限") {
+		t.Errorf("LoadDOCX() inside archive error = %v, want size limit error", err)
+	}
+}
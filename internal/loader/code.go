@@ -0,0 +1,62 @@
+package loader
+
+import (
+	"mini-rag-go/internal/models"
+	"path/filepath"
+	"strings"
+)
+
+// codeLanguages 源代码扩展名对应的语言
+var codeLanguages = map[string]string{
+	".go":   "go",
+	".sh":   "shell",
+	".bash": "shell",
+	".zsh":  "shell",
+	".py":   "python",
+	".js":   "javascript",
+	".ts":   "typescript",
+	".java": "java",
+	".c":    "c",
+	".h":    "c",
+	".cpp":  "cpp",
+	".rs":   "rust",
+	".rb":   "ruby",
+	".sql":  "sql",
+}
+
+// CodeExtensions 返回代码加载器支持的扩展名
+func CodeExtensions() []string {
+	exts := make([]string, 0, len(codeLanguages))
+	for ext := range codeLanguages {
+		exts = append(exts, ext)
+	}
+	return exts
+}
+
+// LoadCode 源代码加载器，整个文件作为一个文档，类型为 "code"，语言写入元数据 "language"；
+// 保留原有的换行符（包括 CRLF），文档块的位置可以直接回指源文件
+func LoadCode(src Source) ([]models.Document, error) {
+	text, enc, err := DecodeText(src.Data)
+	if err != nil {
+		return nil, err
+	}
+	doc := newDocument(src, "", text, "code")
+	doc.Metadata["language"] = codeLanguage(src.Name, text)
+	markSourceOffsets(doc, src.Data, enc)
+	return tagEncoding([]models.Document{doc}, enc), nil
+}
+
+// codeLanguage 按扩展名判断语言，没有扩展名时看 shebang 行
+func codeLanguage(name, text string) string {
+	if lang, ok := codeLanguages[strings.ToLower(filepath.Ext(name))]; ok {
+		return lang
+	}
+	firstLine, _, _ := strings.Cut(text, "\n")
+	switch {
+	case strings.Contains(firstLine, "python"):
+		return "python"
+	case strings.Contains(firstLine, "sh"):
+		return "shell"
+	}
+	return "text"
+}
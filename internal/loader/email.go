@@ -0,0 +1,270 @@
+package loader
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"mini-rag-go/internal/models"
+	"net/mail"
+	"regexp"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+	"golang.org/x/text/encoding/htmlindex"
+)
+
+// replyHeaderRe 回复邮件中引用历史的起始行
+var replyHeaderRe = regexp.MustCompile(`(?i)^(on\s.+wrote:\s*$|在.+写道[：:]\s*$|-{2,}\s*(original message|原始邮件|forwarded message|转发邮件)\s*-{2,})`)
+
+// headerDecoder 解码 RFC 2047 编码的邮件头，支持GBK等字符集
+var headerDecoder = &mime.WordDecoder{CharsetReader: charsetReader}
+
+// addressParser 解析发件人地址，显示名按 headerDecoder 解码
+var addressParser = &mail.AddressParser{WordDecoder: headerDecoder}
+
+// LoadEmail 加载单封 .eml 邮件
+func LoadEmail(src Source) ([]models.Document, error) {
+	doc, err := parseEmail(src, "", src.Data)
+	if err != nil {
+		return nil, err
+	}
+	return []models.Document{doc}, nil
+}
+
+// LoadMbox 加载 mbox 邮箱文件，每封邮件生成一个文档
+func LoadMbox(src Source) ([]models.Document, error) {
+	var documents []models.Document
+	for i, raw := range splitMbox(src.Data) {
+		doc, err := parseEmail(src, fmt.Sprintf("m%d", i+1), raw)
+		if err != nil {
+			fmt.Printf("警告：%s 第%d封邮件解析失败：%v\n", src.Name, i+1, err)
+			continue
+		}
+		documents = append(documents, doc)
+	}
+	return documents, nil
+}
+
+// parseEmail 解析邮件，提取正文并去掉引用的回复历史
+func parseEmail(src Source, suffix string, raw []byte) (models.Document, error) {
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		return models.Document{}, fmt.Errorf("解析邮件失败：%v", err)
+	}
+	body, err := emailBody(msg.Header.Get("Content-Type"), msg.Header.Get("Content-Transfer-Encoding"), msg.Body)
+	if err != nil {
+		return models.Document{}, err
+	}
+	body = stripQuotedReply(body)
+
+	subject := decodeHeader(msg.Header.Get("Subject"))
+	content := body
+	if subject != "" {
+		content = subject + "\n" + body
+	}
+	doc := newDocument(src, suffix, strings.TrimSpace(content), "email")
+	if subject != "" {
+		doc.Metadata["subject"] = subject
+		doc.Metadata["title"] = subject
+	}
+	//from 只保存邮箱地址，便于按发件人精确过滤，显示名单独保存
+	if raw := msg.Header.Get("From"); raw != "" {
+		if addr, err := addressParser.Parse(raw); err == nil {
+			doc.Metadata["from"] = addr.Address
+			if addr.Name != "" {
+				doc.Metadata["from_name"] = addr.Name
+			}
+		} else if from := decodeHeader(raw); from != "" {
+			doc.Metadata["from"] = from
+		}
+	}
+	if date, err := msg.Header.Date(); err == nil {
+		doc.Metadata["date"] = date.Format(time.RFC3339)
+	}
+	messageID := trimAngle(msg.Header.Get("Message-ID"))
+	if messageID != "" {
+		doc.Metadata["message_id"] = messageID
+	}
+	//线程ID取 References 的第一个，其次 In-Reply-To，都没有时是线程的首封邮件
+	threadID := messageID
+	if refs := strings.Fields(msg.Header.Get("References")); len(refs) > 0 {
+		threadID = trimAngle(refs[0])
+	} else if reply := trimAngle(msg.Header.Get("In-Reply-To")); reply != "" {
+		threadID = reply
+	}
+	if threadID != "" {
+		doc.Metadata["thread_id"] = threadID
+	}
+	return doc, nil
+}
+
+// emailBody 解码邮件正文，多部分邮件优先取 text/plain，其次 text/html
+func emailBody(contentType, transferEncoding string, r io.Reader) (string, error) {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType, params = "text/plain", map[string]string{}
+	}
+	r = transferDecoder(transferEncoding, r)
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		mr := multipart.NewReader(r, params["boundary"])
+		var plain, htmlText string
+		for {
+			part, err := mr.NextPart()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return "", fmt.Errorf("解析邮件正文失败：%v", err)
+			}
+			//跳过附件
+			if disposition, _, _ := mime.ParseMediaType(part.Header.Get("Content-Disposition")); disposition == "attachment" {
+				continue
+			}
+			text, err := emailBody(part.Header.Get("Content-Type"), part.Header.Get("Content-Transfer-Encoding"), part)
+			if err != nil || text == "" {
+				continue
+			}
+			partType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+			if partType == "text/html" {
+				if htmlText == "" {
+					htmlText = text
+				}
+			} else if plain == "" {
+				plain = text
+			}
+		}
+		if plain != "" {
+			return plain, nil
+		}
+		return htmlText, nil
+	}
+	if !strings.HasPrefix(mediaType, "text/") {
+		return "", nil
+	}
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return "", fmt.Errorf("读取邮件正文失败：%v", err)
+	}
+	text, err := decodeCharset(data, params["charset"])
+	if err != nil {
+		return "", err
+	}
+	if mediaType == "text/html" {
+		root, err := html.Parse(strings.NewReader(text))
+		if err != nil {
+			return "", fmt.Errorf("解析HTML正文失败：%v", err)
+		}
+		var sb strings.Builder
+		writeHTMLText(&sb, root)
+		return normalizeLines(sb.String()), nil
+	}
+	return strings.ReplaceAll(text, "\r\n", "\n"), nil
+}
+
+// transferDecoder 按 Content-Transfer-Encoding 解码
+func transferDecoder(encoding string, r io.Reader) io.Reader {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "quoted-printable":
+		return quotedprintable.NewReader(r)
+	case "base64":
+		return base64.NewDecoder(base64.StdEncoding, r)
+	}
+	return r
+}
+
+// decodeCharset 按声明的字符集转换为UTF-8，未声明时自动检测
+func decodeCharset(data []byte, charset string) (string, error) {
+	charset = strings.ToLower(strings.TrimSpace(charset))
+	if charset == "" || charset == "utf-8" || charset == "us-ascii" {
+		text, _, err := DecodeText(data)
+		return text, err
+	}
+	enc, err := htmlindex.Get(charset)
+	if err != nil {
+		text, _, err := DecodeText(data)
+		return text, err
+	}
+	decoded, err := enc.NewDecoder().Bytes(data)
+	if err != nil {
+		return "", fmt.Errorf("按 %s 解码失败：%v", charset, err)
+	}
+	return string(decoded), nil
+}
+
+// charsetReader 为 mime.WordDecoder 提供字符集转换
+func charsetReader(charset string, input io.Reader) (io.Reader, error) {
+	enc, err := htmlindex.Get(charset)
+	if err != nil {
+		return nil, err
+	}
+	return enc.NewDecoder().Reader(input), nil
+}
+
+// decodeHeader 解码邮件头
+func decodeHeader(value string) string {
+	decoded, err := headerDecoder.DecodeHeader(value)
+	if err != nil {
+		return strings.TrimSpace(value)
+	}
+	return strings.TrimSpace(decoded)
+}
+
+// stripQuotedReply 去掉以 ">" 开头的引用行以及回复头之后的历史内容
+func stripQuotedReply(body string) string {
+	var lines []string
+	for _, line := range strings.Split(body, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if replyHeaderRe.MatchString(trimmed) {
+			break
+		}
+		if strings.HasPrefix(trimmed, ">") {
+			continue
+		}
+		lines = append(lines, strings.TrimRight(line, " \t\r"))
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// splitMbox 按 "From " 分隔行拆分 mbox 文件
+func splitMbox(data []byte) [][]byte {
+	var messages [][]byte
+	var current bytes.Buffer
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	prevBlank := true
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if prevBlank && bytes.HasPrefix(line, []byte("From ")) {
+			if current.Len() > 0 {
+				messages = append(messages, append([]byte(nil), current.Bytes()...))
+				current.Reset()
+			}
+			prevBlank = false
+			continue
+		}
+		//mboxrd 格式中正文里的 ">From " 需要还原
+		if bytes.HasPrefix(line, []byte(">From ")) {
+			line = line[1:]
+		}
+		current.Write(line)
+		current.WriteByte('\n')
+		prevBlank = len(bytes.TrimSpace(line)) == 0
+	}
+	if len(bytes.TrimSpace(current.Bytes())) > 0 {
+		messages = append(messages, current.Bytes())
+	}
+	return messages
+}
+
+// trimAngle 去掉消息ID两侧的尖括号
+func trimAngle(id string) string {
+	return strings.Trim(strings.TrimSpace(id), "<>")
+}
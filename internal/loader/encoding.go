@@ -0,0 +1,124 @@
+package loader
+
+import (
+	"bytes"
+	"errors"
+	"mini-rag-go/internal/models"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/korean"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/traditionalchinese"
+	xunicode "golang.org/x/text/encoding/unicode"
+)
+
+// ErrUndecodable 无法识别文本编码
+var ErrUndecodable = errors.New("无法识别文件编码")
+
+// minDecodeScore 转码结果中可读字符的最低比例
+const minDecodeScore = 0.95
+
+// cjkEncodings 依次尝试的常见中日韩编码
+var cjkEncodings = []struct {
+	name string
+	enc  encoding.Encoding
+}{
+	{"gb18030", simplifiedchinese.GB18030},
+	{"big5", traditionalchinese.Big5},
+	{"shift_jis", japanese.ShiftJIS},
+	{"euc-kr", korean.EUCKR},
+}
+
+// DecodeText 将文本内容转换为UTF-8，返回内容和识别出的编码名称；
+// 支持BOM检测，非UTF-8内容依次尝试GB18030（兼容GBK）、Big5、Shift_JIS、EUC-KR，
+// 取可读字符比例最高的结果，比例相同时优先靠前的编码
+func DecodeText(data []byte) (string, string, error) {
+	switch {
+	case bytes.HasPrefix(data, []byte{0xEF, 0xBB, 0xBF}):
+		data = data[3:]
+		if !utf8.Valid(data) {
+			return "", "", ErrUndecodable
+		}
+		return string(data), "utf-8", nil
+	case bytes.HasPrefix(data, []byte{0xFF, 0xFE}):
+		return decodeWith(xunicode.UTF16(xunicode.LittleEndian, xunicode.ExpectBOM), data, "utf-16le")
+	case bytes.HasPrefix(data, []byte{0xFE, 0xFF}):
+		return decodeWith(xunicode.UTF16(xunicode.BigEndian, xunicode.ExpectBOM), data, "utf-16be")
+	}
+	if utf8.Valid(data) {
+		return string(data), "utf-8", nil
+	}
+	//含有NUL字节的多半是二进制文件
+	if bytes.IndexByte(data, 0) >= 0 {
+		return "", "", ErrUndecodable
+	}
+
+	best, bestName, bestScore := "", "", 0.0
+	for _, candidate := range cjkEncodings {
+		decoded, err := candidate.enc.NewDecoder().Bytes(data)
+		if err != nil {
+			continue
+		}
+		text := string(decoded)
+		if score := readableScore(text); score > bestScore {
+			best, bestName, bestScore = text, candidate.name, score
+		}
+	}
+	if bestScore < minDecodeScore {
+		return "", "", ErrUndecodable
+	}
+	return best, bestName, nil
+}
+
+// tagEncoding 为非UTF-8来源的文档记录原始编码
+func tagEncoding(docs []models.Document, enc string) []models.Document {
+	if enc == "utf-8" {
+		return docs
+	}
+	for _, doc := range docs {
+		doc.Metadata["encoding"] = enc
+	}
+	return docs
+}
+
+// decodeWith 使用指定编码解码
+func decodeWith(enc encoding.Encoding, data []byte, name string) (string, string, error) {
+	decoded, err := enc.NewDecoder().Bytes(data)
+	if err != nil {
+		return "", "", ErrUndecodable
+	}
+	return string(decoded), name, nil
+}
+
+// readableScore 计算常见可读字符所占比例，替换字符、私用区和控制字符视为不可读
+func readableScore(text string) float64 {
+	total, readable := 0, 0
+	for _, r := range text {
+		total++
+		switch {
+		case r == utf8.RuneError:
+		case r < 0x80:
+			if r >= 0x20 || r == '\n' || r == '\r' || r == '\t' {
+				readable++
+			}
+		case r >= 0x4E00 && r <= 0x9FFF, // 中日韩统一表意文字
+			r >= 0x3000 && r <= 0x30FF, // 中日标点、假名
+			r >= 0xFF00 && r <= 0xFFEF, // 全角字符
+			r >= 0xAC00 && r <= 0xD7AF, // 韩文音节
+			r >= 0x2000 && r <= 0x206F: // 通用标点
+			readable++
+		case unicode.Is(unicode.Co, r):
+		case unicode.IsLetter(r) || unicode.IsPunct(r) || unicode.IsSpace(r):
+			//其他文字按半个计，降低误判为冷僻编码的概率
+			total++
+			readable++
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+	return float64(readable) / float64(total)
+}
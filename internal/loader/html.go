@@ -0,0 +1,210 @@
+package loader
+
+import (
+	"fmt"
+	"mini-rag-go/internal/models"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// htmlSkipTags 不包含正文的样板元素
+var htmlSkipTags = map[atom.Atom]bool{
+	atom.Script:   true,
+	atom.Style:    true,
+	atom.Noscript: true,
+	atom.Template: true,
+	atom.Nav:      true,
+	atom.Aside:    true,
+	atom.Form:     true,
+	atom.Iframe:   true,
+	atom.Svg:      true,
+	atom.Button:   true,
+}
+
+// htmlBlockTags 块级元素，前后换行
+var htmlBlockTags = map[atom.Atom]bool{
+	atom.P: true, atom.Div: true, atom.Section: true, atom.Article: true, atom.Main: true,
+	atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true,
+	atom.Ul: true, atom.Ol: true, atom.Li: true, atom.Dl: true, atom.Dt: true, atom.Dd: true,
+	atom.Table: true, atom.Tr: true, atom.Pre: true, atom.Blockquote: true, atom.Br: true,
+	atom.Hr: true, atom.Figure: true, atom.Figcaption: true, atom.Caption: true,
+	atom.Header: true, atom.Footer: true,
+}
+
+// LoadHTML HTML加载器，去掉脚本、样式和导航等样板内容，保留正文文本
+func LoadHTML(src Source) ([]models.Document, error) {
+	text, enc, err := DecodeText(src.Data)
+	if err != nil {
+		return nil, err
+	}
+	root, err := html.Parse(strings.NewReader(text))
+	if err != nil {
+		return nil, fmt.Errorf("解析HTML失败：%v", err)
+	}
+	title, canonical := htmlHead(root)
+
+	//优先提取 <main> 或 <article> 中的内容
+	body := findElement(root, atom.Main)
+	if body == nil {
+		body = findElement(root, atom.Article)
+	}
+	if body == nil {
+		body = findElement(root, atom.Body)
+	}
+	if body == nil {
+		body = root
+	}
+	var sb strings.Builder
+	writeHTMLText(&sb, body)
+	content := normalizeLines(sb.String())
+	if content == "" {
+		return nil, fmt.Errorf("HTML中没有可提取的正文")
+	}
+
+	doc := newDocument(src, "", content, "html")
+	if title != "" {
+		doc.Metadata["title"] = title
+	}
+	if canonical != "" {
+		doc.Metadata["url"] = canonical
+	}
+	return tagEncoding([]models.Document{doc}, enc), nil
+}
+
+// htmlHead 提取 <title> 和 canonical 链接
+func htmlHead(root *html.Node) (title, canonical string) {
+	var visit func(n *html.Node)
+	visit = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			switch n.DataAtom {
+			case atom.Title:
+				if title == "" {
+					title = strings.Join(strings.Fields(nodeText(n)), " ")
+				}
+			case atom.Link:
+				if canonical == "" && strings.EqualFold(attr(n, "rel"), "canonical") {
+					canonical = strings.TrimSpace(attr(n, "href"))
+				}
+			case atom.Body:
+				return
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			visit(c)
+		}
+	}
+	visit(root)
+	return title, canonical
+}
+
+// writeHTMLText 将节点转换为可读文本
+func writeHTMLText(sb *strings.Builder, n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		//源码中的换行不代表段落
+		sb.WriteString(strings.NewReplacer("\r", " ", "\n", " ").Replace(n.Data))
+		return
+	case html.ElementNode:
+		if htmlSkipTags[n.DataAtom] || isPageChrome(n) || strings.EqualFold(attr(n, "role"), "navigation") ||
+			strings.EqualFold(attr(n, "aria-hidden"), "true") {
+			return
+		}
+		if n.DataAtom == atom.Pre {
+			sb.WriteString("\n" + nodeText(n) + "\n")
+			return
+		}
+	}
+	block := n.Type == html.ElementNode && htmlBlockTags[n.DataAtom]
+	if block {
+		sb.WriteString("\n")
+	}
+	switch n.DataAtom {
+	case atom.Li:
+		sb.WriteString("- ")
+	case atom.Td, atom.Th:
+		//同一行的单元格用竖线分隔
+		if prevElement(n) != nil {
+			sb.WriteString(" | ")
+		}
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		writeHTMLText(sb, c)
+	}
+	if block {
+		sb.WriteString("\n")
+	}
+}
+
+// isPageChrome 判断是否为页面级的页眉或页脚：<header>、<footer> 不在 <article>、<main>、<section> 内时
+// 是网站的样板内容，在其中时是文章自身的标题或署名，需要保留
+func isPageChrome(n *html.Node) bool {
+	if n.DataAtom != atom.Header && n.DataAtom != atom.Footer {
+		return false
+	}
+	for p := n.Parent; p != nil; p = p.Parent {
+		switch p.DataAtom {
+		case atom.Article, atom.Main, atom.Section:
+			return false
+		}
+	}
+	return true
+}
+
+// normalizeLines 压缩行内空白，去掉空行
+func normalizeLines(text string) string {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.Join(strings.Fields(line), " ")
+		if line != "" && line != "-" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// findElement 深度优先查找第一个指定元素
+func findElement(n *html.Node, a atom.Atom) *html.Node {
+	if n.Type == html.ElementNode && n.DataAtom == a {
+		return n
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if found := findElement(c, a); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// prevElement 返回前一个元素兄弟节点
+func prevElement(n *html.Node) *html.Node {
+	for p := n.PrevSibling; p != nil; p = p.PrevSibling {
+		if p.Type == html.ElementNode {
+			return p
+		}
+	}
+	return nil
+}
+
+// nodeText 返回节点下的全部文本
+func nodeText(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var sb strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		sb.WriteString(nodeText(c))
+	}
+	return sb.String()
+}
+
+// attr 读取元素属性
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if strings.EqualFold(a.Key, key) {
+			return a.Val
+		}
+	}
+	return ""
+}
@@ -0,0 +1,38 @@
+package loader
+
+import "testing"
+
+func TestLoadHTMLHeaderFooter(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		want string
+	}{
+		{
+			name: "文章内的页眉保留",
+			html: `<body><header>站点导航</header><article><header><h1>退款政策</h1></header><p>七天内可退款。</p><footer>作者：客服部</footer></article><footer>版权所有</footer></body>`,
+			want: "退款政策\n七天内可退款。\n作者：客服部",
+		},
+		{
+			name: "页面级页眉页脚去掉",
+			html: `<body><header>站点导航</header><div><p>正文内容。</p></div><footer>版权所有</footer></body>`,
+			want: "正文内容。",
+		},
+		{
+			name: "章节内的页眉保留",
+			html: `<body><header>站点导航</header><section><header><h2>退货</h2></header><p>保留包装。</p></section></body>`,
+			want: "退货\n保留包装。",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			docs, err := LoadHTML(Source{Name: "a.html", Data: []byte(tt.html)})
+			if err != nil {
+				t.Fatalf("LoadHTML() error = %v", err)
+			}
+			if got := docs[0].Content; got != tt.want {
+				t.Errorf("LoadHTML() content = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
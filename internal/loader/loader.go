@@ -0,0 +1,281 @@
+package loader
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"mini-rag-go/internal/models"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ErrUnsupported 没有可用的加载器
+var ErrUnsupported = errors.New("不支持的文件类型")
+
+// Source 待加载的文件
+type Source struct {
+	Name    string // 文件名
+	Path    string // 文件路径
+	RelPath string // 相对文档根目录的路径，用于生成文档ID
+	Archive string // 所在压缩包的相对路径，不在压缩包中时为空
+	Data    []byte // 文件内容
+
+	budget *archiveBudget // 所在压缩包剩余的解压配额
+}
+
+// zipBudget 返回解压文件内部zip结构时使用的配额：压缩包内的文件共用压缩包的配额，
+// 其他文件使用默认配额
+func (s Source) zipBudget() *archiveBudget {
+	if s.budget != nil {
+		return s.budget
+	}
+	return newArchiveBudget(DefaultArchiveMaxBytes, DefaultArchiveMaxEntries)
+}
+
+// DocID 基于相对路径生成文档ID，suffix 用于区分同一文件拆出的多个文档
+func (s Source) DocID(suffix string) string {
+	id := s.RelPath
+	if id == "" {
+		id = s.Name
+	}
+	if suffix != "" {
+		id += "_" + suffix
+	}
+	return id
+}
+
+// Loader 文档加载器接口
+type Loader interface {
+	Load(src Source) ([]models.Document, error)
+}
+
+// LoaderFunc 将普通函数适配为 Loader
+type LoaderFunc func(src Source) ([]models.Document, error)
+
+// Load 调用函数本身
+func (f LoaderFunc) Load(src Source) ([]models.Document, error) {
+	return f(src)
+}
+
+// Registry 加载器注册表，按扩展名或MIME类型查找加载器
+type Registry struct {
+	mu     sync.RWMutex
+	byExt  map[string]Loader
+	byMIME map[string]Loader
+}
+
+// NewRegistry 创建空的注册表
+func NewRegistry() *Registry {
+	return &Registry{
+		byExt:  make(map[string]Loader),
+		byMIME: make(map[string]Loader),
+	}
+}
+
+// DefaultRegistry 创建包含内置加载器的注册表
+func DefaultRegistry() *Registry {
+	r := NewRegistry()
+	r.Register(".txt", LoaderFunc(LoadText))
+	r.Register(".pdf", LoaderFunc(LoadPDF))
+	r.Register(".md", LoaderFunc(LoadMarkdown))
+	r.Register(".markdown", LoaderFunc(LoadMarkdown))
+	r.Register(".html", LoaderFunc(LoadHTML))
+	r.Register(".htm", LoaderFunc(LoadHTML))
+	r.Register(".xhtml", LoaderFunc(LoadHTML))
+	r.Register(".docx", LoaderFunc(LoadDOCX))
+	r.Register(".xlsx", LoaderFunc(LoadXLSX))
+	records := NewRecordLoader(RecordMapping{})
+	r.Register(".csv", records)
+	r.Register(".jsonl", records)
+	r.Register(".ndjson", records)
+	r.Register(".eml", LoaderFunc(LoadEmail))
+	r.Register(".mbox", LoaderFunc(LoadMbox))
+	for _, ext := range CodeExtensions() {
+		r.Register(ext, LoaderFunc(LoadCode))
+	}
+	archives := NewArchiveLoader(r, DefaultArchiveMaxBytes, DefaultArchiveMaxEntries)
+	for _, ext := range []string{".zip", ".tar", ".tar.gz", ".tgz"} {
+		r.Register(ext, archives)
+	}
+	r.RegisterMIME("text/plain", LoaderFunc(LoadText))
+	r.RegisterMIME("application/pdf", LoaderFunc(LoadPDF))
+	r.RegisterMIME("text/html", LoaderFunc(LoadHTML))
+	return r
+}
+
+// Register 按扩展名注册加载器，扩展名如 ".md"，不区分大小写
+func (r *Registry) Register(ext string, l Loader) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ext = strings.ToLower(ext)
+	if !strings.HasPrefix(ext, ".") {
+		ext = "." + ext
+	}
+	r.byExt[ext] = l
+}
+
+// RegisterMIME 按MIME类型注册加载器，用于没有扩展名的文件
+func (r *Registry) RegisterMIME(mimeType string, l Loader) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.byMIME[strings.ToLower(mimeType)] = l
+}
+
+// Lookup 查找加载器，返回加载器和识别出的文件类型
+func (r *Registry) Lookup(name string, data []byte) (Loader, string, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	lower := strings.ToLower(name)
+	//按最长后缀匹配，支持 ".tar.gz" 这类多段扩展名
+	var matched string
+	for ext := range r.byExt {
+		if strings.HasSuffix(lower, ext) && len(ext) > len(matched) {
+			matched = ext
+		}
+	}
+	if matched != "" {
+		return r.byExt[matched], matched, true
+	}
+	if ext := filepath.Ext(lower); ext != "" {
+		return nil, ext, false
+	}
+	//没有扩展名时按内容嗅探
+	mimeType := detectMIME(data)
+	if l, ok := r.byMIME[mimeType]; ok {
+		return l, mimeType, true
+	}
+	return nil, mimeType, false
+}
+
+// Load 使用匹配的加载器加载文件
+func (r *Registry) Load(src Source) ([]models.Document, error) {
+	l, fileType, ok := r.Lookup(src.Name, src.Data)
+	if !ok {
+		return nil, fmt.Errorf("%w：%s", ErrUnsupported, fileType)
+	}
+	return l.Load(src)
+}
+
+// detectMIME 嗅探内容的MIME类型，去掉参数部分
+func detectMIME(data []byte) string {
+	mimeType := http.DetectContentType(data)
+	if i := strings.Index(mimeType, ";"); i >= 0 {
+		mimeType = mimeType[:i]
+	}
+	return strings.TrimSpace(mimeType)
+}
+
+// newDocument 创建带通用元数据的文档
+func newDocument(src Source, suffix, content, docType string) models.Document {
+	doc := models.Document{
+		ID:       src.DocID(suffix),
+		Content:  content,
+		Filename: src.Name,
+		Metadata: map[string]string{
+			"filename": src.Name,
+			"path":     src.Path,
+			"rel_path": src.DocID(""),
+			"type":     docType,
+		},
+	}
+	if src.Archive != "" {
+		doc.Metadata["archive"] = src.Archive
+	}
+	return doc
+}
+
+// LoadText 纯文本加载器
+func LoadText(src Source) ([]models.Document, error) {
+	text, enc, err := DecodeText(src.Data)
+	if err != nil {
+		return nil, err
+	}
+	//没有扩展名的脚本按源代码处理，.txt 等有扩展名的文件即使以 "#!" 开头也按文本处理
+	if filepath.Ext(src.Name) == "" && strings.HasPrefix(text, "#!") {
+		return LoadCode(src)
+	}
+	doc := newDocument(src, "", text, "text")
+	markSourceOffsets(doc, src.Data, enc)
+	return tagEncoding([]models.Document{doc}, enc), nil
+}
+
+// markSourceOffsets 文档内容与源文件逐字节一致（UTF-8，可能带BOM）时，标记文档块的位置可以回指源文件，
+// 并记录BOM占用的字符数和字节数，分块后按此换算为源文件中的位置；转码的文件不标记
+func markSourceOffsets(doc models.Document, data []byte, enc string) {
+	if enc != "utf-8" {
+		return
+	}
+	doc.Metadata["offsets"] = "source"
+	if bytes.HasPrefix(data, []byte{0xEF, 0xBB, 0xBF}) {
+		doc.Metadata["source_rune_offset"] = "1"
+		doc.Metadata["source_byte_offset"] = "3"
+	}
+}
+
+// Report 一次加载过程的统计
+type Report struct {
+	Files     int                 // 成功加载的文件数
+	Documents int                 // 生成的文档数
+	Unknown   map[string][]string // 文件类型 -> 未识别的文件
+	Failed    map[string]string   // 文件 -> 失败原因
+}
+
+// NewReport 创建加载统计
+func NewReport() *Report {
+	return &Report{
+		Unknown: make(map[string][]string),
+		Failed:  make(map[string]string),
+	}
+}
+
+// AddLoaded 记录加载成功的文件
+func (rp *Report) AddLoaded(docs int) {
+	rp.Files++
+	rp.Documents += docs
+}
+
+// AddUnknown 记录无法识别类型的文件
+func (rp *Report) AddUnknown(fileType, path string) {
+	if fileType == "" {
+		fileType = "未知"
+	}
+	rp.Unknown[fileType] = append(rp.Unknown[fileType], path)
+}
+
+// AddFailed 记录加载失败的文件
+func (rp *Report) AddFailed(path string, err error) {
+	rp.Failed[path] = err.Error()
+}
+
+// Summary 生成可读的统计摘要
+func (rp *Report) Summary() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("加载完成：%d 个文件，%d 个文档", rp.Files, rp.Documents))
+	if len(rp.Unknown) > 0 {
+		sb.WriteString("\n未识别的文件类型：")
+		for _, fileType := range sortedKeys(rp.Unknown) {
+			files := rp.Unknown[fileType]
+			sb.WriteString(fmt.Sprintf("\n  %s（%d 个）：%s", fileType, len(files), strings.Join(files, ", ")))
+		}
+	}
+	if len(rp.Failed) > 0 {
+		sb.WriteString("\n加载失败的文件：")
+		for _, path := range sortedKeys(rp.Failed) {
+			sb.WriteString(fmt.Sprintf("\n  %s：%s", path, rp.Failed[path]))
+		}
+	}
+	return sb.String()
+}
+
+// sortedKeys 返回排序后的键
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,36 @@
+package loader
+
+import "testing"
+
+func TestLoadTextShebang(t *testing.T) {
+	tests := []struct {
+		name         string
+		file         string
+		data         string
+		wantType     string
+		wantLanguage string
+	}{
+		{name: "没有扩展名的脚本", file: "deploy", data: "#!/bin/sh\necho ok\n", wantType: "code", wantLanguage: "shell"},
+		{name: "Python脚本", file: "run", data: "#!/usr/bin/env python3\nprint(1)\n", wantType: "code", wantLanguage: "python"},
+		{name: "txt文件", file: "notes.txt", data: "#!开头的说明\n退款七天内办理。\n", wantType: "text"},
+		{name: "普通文本", file: "README", data: "退款七天内办理。\n", wantType: "text"},
+	}
+	registry := DefaultRegistry()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			docs, err := registry.Load(Source{Name: tt.file, Data: []byte(tt.data)})
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if got := docs[0].Metadata["type"]; got != tt.wantType {
+				t.Errorf("type = %q, want %q", got, tt.wantType)
+			}
+			if got := docs[0].Metadata["language"]; got != tt.wantLanguage {
+				t.Errorf("language = %q, want %q", got, tt.wantLanguage)
+			}
+			if docs[0].Content != tt.data {
+				t.Errorf("content = %q, want %q", docs[0].Content, tt.data)
+			}
+		})
+	}
+}
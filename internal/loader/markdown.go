@@ -0,0 +1,187 @@
+package loader
+
+import (
+	"fmt"
+	"mini-rag-go/internal/models"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	mdHeadingRe  = regexp.MustCompile(`^ {0,3}(#{1,6})[ \t]+(.*?)[ \t#]*$`)
+	mdSetextRe   = regexp.MustCompile(`^ {0,3}(=+|-+)[ \t]*$`)
+	mdFenceRe    = regexp.MustCompile("^ {0,3}(```+|~~~+)")
+	mdListRe     = regexp.MustCompile(`^(\s*)([-*+]|\d+[.)])[ \t]+(.*)$`)
+	mdTableSepRe = regexp.MustCompile(`^\s*\|?\s*:?-{3,}:?\s*(\|\s*:?-{3,}:?\s*)*\|?\s*$`)
+	mdImageRe    = regexp.MustCompile(`!\[([^\]]*)\]\([^)]*\)`)
+	mdLinkRe     = regexp.MustCompile(`\[([^\]]+)\]\([^)]*\)`)
+	mdEmphasisRe = regexp.MustCompile(`(\*\*|__|~~)(.+?)(\*\*|__|~~)`)
+	mdCodeRe     = regexp.MustCompile("`([^`]+)`")
+)
+
+// LoadMarkdown Markdown加载器，按标题拆分章节，每个章节带有标题路径元数据
+func LoadMarkdown(src Source) ([]models.Document, error) {
+	text, enc, err := DecodeText(src.Data)
+	if err != nil {
+		return nil, err
+	}
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	lines = skipFrontMatter(lines)
+
+	sb := newSectionBuilder()
+	inFence := ""
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		//代码块内的内容原样保留，其中的 # 不是标题
+		if m := mdFenceRe.FindStringSubmatch(line); m != nil {
+			marker := m[1][:3]
+			if inFence == "" {
+				inFence = marker
+			} else if strings.HasPrefix(m[1], inFence) {
+				inFence = ""
+			}
+			sb.line(line)
+			continue
+		}
+		if inFence != "" {
+			sb.line(line)
+			continue
+		}
+
+		if m := mdHeadingRe.FindStringSubmatch(line); m != nil {
+			sb.heading(len(m[1]), cleanInline(m[2]))
+			continue
+		}
+		if i+1 < len(lines) && isSetextParagraph(line) && mdSetextRe.MatchString(lines[i+1]) {
+			level := 2
+			if strings.HasPrefix(strings.TrimSpace(lines[i+1]), "=") {
+				level = 1
+			}
+			sb.heading(level, cleanInline(strings.TrimSpace(line)))
+			i++
+			continue
+		}
+		sb.line(formatMarkdownLine(line))
+	}
+	return tagEncoding(sb.documents(src, "markdown"), enc), nil
+}
+
+// section 文档中的一个章节
+type section struct {
+	headings []string // 从一级标题到当前标题的路径
+	lines    []string
+}
+
+// sectionBuilder 按标题层级收集章节，供有标题结构的加载器共用
+type sectionBuilder struct {
+	sections []*section
+	headings []string
+	current  *section
+}
+
+// newSectionBuilder 创建章节收集器
+func newSectionBuilder() *sectionBuilder {
+	return &sectionBuilder{current: &section{}}
+}
+
+// heading 遇到标题时开始新章节
+func (b *sectionBuilder) heading(level int, title string) {
+	b.sections = append(b.sections, b.current)
+	if level <= len(b.headings) {
+		b.headings = b.headings[:level-1]
+	}
+	//跳级的标题（如 # 后直接 ###）用空位补齐
+	for len(b.headings) < level-1 {
+		b.headings = append(b.headings, "")
+	}
+	b.headings = append(b.headings, title)
+	b.current = &section{headings: append([]string(nil), b.headings...)}
+}
+
+// line 向当前章节追加一行
+func (b *sectionBuilder) line(line string) {
+	b.current.lines = append(b.current.lines, line)
+}
+
+// documents 将非空章节转换为文档，标题路径写入元数据
+func (b *sectionBuilder) documents(src Source, docType string) []models.Document {
+	sections := append(b.sections, b.current)
+	var documents []models.Document
+	for _, section := range sections {
+		body := strings.TrimSpace(strings.Join(section.lines, "\n"))
+		if body == "" {
+			continue
+		}
+		breadcrumb := joinHeadings(section.headings)
+		content := body
+		if len(section.headings) > 0 {
+			content = section.headings[len(section.headings)-1] + "\n" + body
+		}
+		doc := newDocument(src, fmt.Sprintf("s%d", len(documents)), content, docType)
+		if breadcrumb != "" {
+			doc.Metadata["section"] = breadcrumb
+			doc.Metadata["heading"] = section.headings[len(section.headings)-1]
+			doc.Metadata["heading_level"] = strconv.Itoa(len(section.headings))
+		}
+		documents = append(documents, doc)
+	}
+	return documents
+}
+
+// skipFrontMatter 跳过文件开头的YAML front matter
+func skipFrontMatter(lines []string) []string {
+	if len(lines) == 0 || strings.TrimSpace(lines[0]) != "---" {
+		return lines
+	}
+	for i := 1; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) == "---" {
+			return lines[i+1:]
+		}
+	}
+	return lines
+}
+
+// isSetextParagraph 判断该行能否作为 Setext 风格标题的文字行
+func isSetextParagraph(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	return trimmed != "" && !mdListRe.MatchString(line) && !strings.HasPrefix(trimmed, "|") &&
+		!strings.HasPrefix(trimmed, ">")
+}
+
+// formatMarkdownLine 去掉行内标记，统一列表符号，表格行保持原样
+func formatMarkdownLine(line string) string {
+	trimmed := strings.TrimSpace(line)
+	if strings.HasPrefix(trimmed, "|") || mdTableSepRe.MatchString(line) && strings.Contains(line, "|") {
+		return trimmed
+	}
+	if m := mdListRe.FindStringSubmatch(line); m != nil {
+		marker := m[2]
+		if marker == "*" || marker == "+" {
+			marker = "-"
+		}
+		return m[1] + marker + " " + cleanInline(m[3])
+	}
+	trimmed = strings.TrimLeft(trimmed, "> ")
+	return cleanInline(trimmed)
+}
+
+// cleanInline 去掉链接、强调、行内代码等标记，保留文字
+func cleanInline(text string) string {
+	text = mdImageRe.ReplaceAllString(text, "$1")
+	text = mdLinkRe.ReplaceAllString(text, "$1")
+	text = mdEmphasisRe.ReplaceAllString(text, "$2")
+	text = mdCodeRe.ReplaceAllString(text, "$1")
+	return text
+}
+
+// joinHeadings 生成标题路径，如 "退款政策 > 退款流程"
+func joinHeadings(headings []string) string {
+	var parts []string
+	for _, h := range headings {
+		if h != "" {
+			parts = append(parts, h)
+		}
+	}
+	return strings.Join(parts, " > ")
+}
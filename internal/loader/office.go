@@ -0,0 +1,496 @@
+package loader
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"mini-rag-go/internal/models"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var headingStyleRe = regexp.MustCompile(`(?i)^(heading|标题)\s*(\d)$`)
+
+// LoadDOCX Word文档加载器，段落转为文本，标题样式转为章节
+func LoadDOCX(src Source) ([]models.Document, error) {
+	zr, err := zip.NewReader(bytes.NewReader(src.Data), int64(len(src.Data)))
+	if err != nil {
+		return nil, fmt.Errorf("打开DOCX失败：%v", err)
+	}
+	//DOCX本身也是zip，按压缩包的配额解压，防止压缩炸弹
+	budget := src.zipBudget()
+	body, err := readZipFile(zr, "word/document.xml", budget)
+	if err != nil {
+		return nil, err
+	}
+	//样式文件可选，用于识别标题级别
+	styles := make(map[string]int)
+	if data, err := readZipFile(zr, "word/styles.xml", budget); err == nil {
+		styles = parseDOCXStyles(data)
+	}
+
+	sb := newSectionBuilder()
+	dec := xml.NewDecoder(bytes.NewReader(body))
+	var para, cell strings.Builder
+	var row []string
+	paraStyle, isList, tableDepth := "", false, 0
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("解析DOCX失败：%v", err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "p":
+				para.Reset()
+				paraStyle, isList = "", false
+			case "pStyle":
+				paraStyle = xmlAttr(t, "val")
+			case "numPr":
+				isList = true
+			case "tab":
+				para.WriteString("\t")
+			case "br", "cr":
+				para.WriteString("\n")
+			case "t":
+				var text string
+				if err := dec.DecodeElement(&text, &t); err == nil {
+					para.WriteString(text)
+				}
+			case "tbl":
+				tableDepth++
+			case "tr":
+				row = row[:0]
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "p":
+				text := strings.TrimSpace(para.String())
+				para.Reset()
+				if tableDepth > 0 {
+					//单元格内的段落先暂存，整行结束时输出
+					if text != "" {
+						if cell.Len() > 0 {
+							cell.WriteString(" ")
+						}
+						cell.WriteString(text)
+					}
+					continue
+				}
+				if text == "" {
+					continue
+				}
+				if level := docxHeadingLevel(paraStyle, styles); level > 0 {
+					sb.heading(level, text)
+				} else if isList {
+					sb.line("- " + text)
+				} else {
+					sb.line(text)
+				}
+			case "tc":
+				row = append(row, cell.String())
+				cell.Reset()
+			case "tr":
+				if line := strings.Join(row, " | "); strings.Trim(line, " |") != "" {
+					sb.line(line)
+				}
+			case "tbl":
+				tableDepth--
+			}
+		}
+	}
+	return sb.documents(src, "docx"), nil
+}
+
+// parseDOCXStyles 解析样式表，返回样式ID到标题级别的映射
+func parseDOCXStyles(data []byte) map[string]int {
+	var doc struct {
+		Styles []struct {
+			ID   string `xml:"styleId,attr"`
+			Name struct {
+				Val string `xml:"val,attr"`
+			} `xml:"name"`
+		} `xml:"style"`
+	}
+	levels := make(map[string]int)
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return levels
+	}
+	for _, s := range doc.Styles {
+		if m := headingStyleRe.FindStringSubmatch(s.Name.Val); m != nil {
+			levels[s.ID], _ = strconv.Atoi(m[2])
+		} else if strings.EqualFold(s.Name.Val, "title") {
+			levels[s.ID] = 1
+		}
+	}
+	return levels
+}
+
+// docxHeadingLevel 根据段落样式判断标题级别，0 表示普通段落
+func docxHeadingLevel(style string, styles map[string]int) int {
+	if style == "" {
+		return 0
+	}
+	if level, ok := styles[style]; ok {
+		return level
+	}
+	if m := headingStyleRe.FindStringSubmatch(style); m != nil {
+		level, _ := strconv.Atoi(m[2])
+		return level
+	}
+	if strings.EqualFold(style, "title") {
+		return 1
+	}
+	return 0
+}
+
+// LoadXLSX Excel加载器，每个工作表生成一个文档，每行转为 "列名: 值" 形式
+func LoadXLSX(src Source) ([]models.Document, error) {
+	zr, err := zip.NewReader(bytes.NewReader(src.Data), int64(len(src.Data)))
+	if err != nil {
+		return nil, fmt.Errorf("打开XLSX失败：%v", err)
+	}
+	budget := src.zipBudget()
+	sheets, date1904, err := parseWorkbook(zr, budget)
+	if err != nil {
+		return nil, err
+	}
+	var shared []string
+	if data, err := readZipFile(zr, "xl/sharedStrings.xml", budget); err == nil {
+		shared = parseSharedStrings(data)
+	}
+	//样式表可选，用于识别日期格式的单元格
+	var dateStyles map[int]bool
+	if data, err := readZipFile(zr, "xl/styles.xml", budget); err == nil {
+		dateStyles = parseDateStyles(data)
+	}
+	dates := xlsxDates{styles: dateStyles, date1904: date1904}
+
+	var documents []models.Document
+	for _, sheet := range sheets {
+		data, err := readZipFile(zr, sheet.path, budget)
+		if err != nil {
+			fmt.Printf("警告：%s 工作表 %s 读取失败：%v\n", src.Name, sheet.name, err)
+			continue
+		}
+		rows, err := parseSheetRows(data, shared, dates)
+		if err != nil {
+			fmt.Printf("警告：%s 工作表 %s 解析失败：%v\n", src.Name, sheet.name, err)
+			continue
+		}
+		content := formatRecords(rows)
+		if content == "" {
+			continue
+		}
+		doc := newDocument(src, "sheet"+strconv.Itoa(len(documents)+1), content, "xlsx")
+		doc.Metadata["sheet"] = sheet.name
+		doc.Metadata["layout"] = "rows"
+		documents = append(documents, doc)
+	}
+	return documents, nil
+}
+
+// xlsxSheet 工作表名称与对应的XML路径
+type xlsxSheet struct {
+	name string
+	path string
+}
+
+// parseWorkbook 读取工作表列表，以及工作簿是否使用1904日期系统
+func parseWorkbook(zr *zip.Reader, budget *archiveBudget) ([]xlsxSheet, bool, error) {
+	data, err := readZipFile(zr, "xl/workbook.xml", budget)
+	if err != nil {
+		return nil, false, err
+	}
+	var workbook struct {
+		Properties struct {
+			Date1904 string `xml:"date1904,attr"`
+		} `xml:"workbookPr"`
+		Sheets []struct {
+			Name string     `xml:"name,attr"`
+			Attr []xml.Attr `xml:",any,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	if err := xml.Unmarshal(data, &workbook); err != nil {
+		return nil, false, fmt.Errorf("解析工作簿失败：%v", err)
+	}
+	targets := make(map[string]string)
+	if data, err := readZipFile(zr, "xl/_rels/workbook.xml.rels", budget); err == nil {
+		var rels struct {
+			Items []struct {
+				ID     string `xml:"Id,attr"`
+				Target string `xml:"Target,attr"`
+			} `xml:"Relationship"`
+		}
+		if xml.Unmarshal(data, &rels) == nil {
+			for _, rel := range rels.Items {
+				target := strings.TrimPrefix(rel.Target, "/")
+				if !strings.HasPrefix(target, "xl/") {
+					target = path.Join("xl", target)
+				}
+				targets[rel.ID] = target
+			}
+		}
+	}
+	var sheets []xlsxSheet
+	for i, s := range workbook.Sheets {
+		sheetPath := fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1)
+		for _, a := range s.Attr {
+			if a.Name.Local == "id" {
+				if target, ok := targets[a.Value]; ok {
+					sheetPath = target
+				}
+			}
+		}
+		sheets = append(sheets, xlsxSheet{name: s.Name, path: sheetPath})
+	}
+	date1904 := workbook.Properties.Date1904 == "1" || workbook.Properties.Date1904 == "true"
+	return sheets, date1904, nil
+}
+
+// parseSharedStrings 读取共享字符串表
+func parseSharedStrings(data []byte) []string {
+	var sst struct {
+		Items []struct {
+			T string `xml:"t"`
+			R []struct {
+				T string `xml:"t"`
+			} `xml:"r"`
+		} `xml:"si"`
+	}
+	if err := xml.Unmarshal(data, &sst); err != nil {
+		return nil
+	}
+	values := make([]string, len(sst.Items))
+	for i, item := range sst.Items {
+		text := item.T
+		//富文本由多个片段组成
+		for _, r := range item.R {
+			text += r.T
+		}
+		values[i] = text
+	}
+	return values
+}
+
+// parseSheetRows 读取工作表中的行，每行为列号到值的映射
+func parseSheetRows(data []byte, shared []string, dates xlsxDates) ([]map[int]string, error) {
+	var sheet struct {
+		Rows []struct {
+			Cells []struct {
+				Ref    string `xml:"r,attr"`
+				Type   string `xml:"t,attr"`
+				Style  int    `xml:"s,attr"`
+				Value  string `xml:"v"`
+				Inline struct {
+					T string `xml:"t"`
+				} `xml:"is"`
+			} `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+	if err := xml.Unmarshal(data, &sheet); err != nil {
+		return nil, err
+	}
+	var rows []map[int]string
+	for _, r := range sheet.Rows {
+		row := make(map[int]string)
+		for i, c := range r.Cells {
+			value := c.Value
+			switch c.Type {
+			case "s":
+				if idx, err := strconv.Atoi(value); err == nil && idx < len(shared) {
+					value = shared[idx]
+				}
+			case "inlineStr":
+				value = c.Inline.T
+			case "b":
+				if value == "1" {
+					value = "TRUE"
+				} else {
+					value = "FALSE"
+				}
+			case "", "n":
+				if dates.styles[c.Style] {
+					value = dates.format(value)
+				}
+			}
+			col := i
+			if c.Ref != "" {
+				col = columnIndex(c.Ref)
+			}
+			if value = strings.TrimSpace(value); value != "" {
+				row[col] = value
+			}
+		}
+		if len(row) > 0 {
+			rows = append(rows, row)
+		}
+	}
+	return rows, nil
+}
+
+// xlsxDates 日期单元格的识别和转换：styles 为日期格式的样式序号
+type xlsxDates struct {
+	styles   map[int]bool
+	date1904 bool
+}
+
+// format 将日期序列号转换为日期文本，只有日期时为 "2006-01-02"，只有时间时为 "15:04:05"；
+// 无法解析时返回原值
+func (d xlsxDates) format(value string) string {
+	serial, err := strconv.ParseFloat(value, 64)
+	if err != nil || serial < 0 {
+		return value
+	}
+	base := time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+	if d.date1904 {
+		base = time.Date(1904, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+	days := math.Floor(serial)
+	seconds := math.Round((serial - days) * 86400)
+	t := base.AddDate(0, 0, int(days)).Add(time.Duration(seconds) * time.Second)
+	switch {
+	case seconds == 0:
+		return t.Format("2006-01-02")
+	case days == 0:
+		return t.Format("15:04:05")
+	}
+	return t.Format("2006-01-02 15:04:05")
+}
+
+// builtinDateFormats 内置的日期时间格式编号，包括中日韩区域的格式
+var builtinDateFormats = map[int]bool{
+	14: true, 15: true, 16: true, 17: true, 18: true, 19: true, 20: true, 21: true, 22: true,
+	27: true, 28: true, 29: true, 30: true, 31: true, 32: true, 33: true, 34: true, 35: true, 36: true,
+	45: true, 46: true, 47: true, 50: true, 51: true, 52: true, 53: true, 54: true, 55: true,
+	56: true, 57: true, 58: true,
+}
+
+// formatLiteralRe 格式串中引号内的文字、转义字符和 [颜色]、[$-409] 等区块；去掉这些之后
+// 含有 dateFormatRe 中的日期时间占位符的是日期格式
+var (
+	formatLiteralRe = regexp.MustCompile(`"[^"]*"|\\.|\[[^\]]*\]`)
+	dateFormatRe    = regexp.MustCompile(`(?i)[ymdhs]`)
+)
+
+// parseDateStyles 读取样式表，返回使用日期格式的单元格样式序号
+func parseDateStyles(data []byte) map[int]bool {
+	var styles struct {
+		NumFmts []struct {
+			ID   int    `xml:"numFmtId,attr"`
+			Code string `xml:"formatCode,attr"`
+		} `xml:"numFmts>numFmt"`
+		CellXfs []struct {
+			NumFmtID int `xml:"numFmtId,attr"`
+		} `xml:"cellXfs>xf"`
+	}
+	if err := xml.Unmarshal(data, &styles); err != nil {
+		return nil
+	}
+	dateFormats := make(map[int]bool)
+	for id := range builtinDateFormats {
+		dateFormats[id] = true
+	}
+	for _, f := range styles.NumFmts {
+		dateFormats[f.ID] = dateFormatRe.MatchString(formatLiteralRe.ReplaceAllString(f.Code, ""))
+	}
+	result := make(map[int]bool)
+	for i, xf := range styles.CellXfs {
+		if dateFormats[xf.NumFmtID] {
+			result[i] = true
+		}
+	}
+	return result
+}
+
+// formatRecords 第一行作为表头，其余每行转为若干 "列名: 值" 行，行之间以空行分隔
+func formatRecords(rows []map[int]string) string {
+	if len(rows) == 0 {
+		return ""
+	}
+	header := rows[0]
+	var blocks []string
+	for _, row := range rows[1:] {
+		cols := make([]int, 0, len(row))
+		for col := range row {
+			cols = append(cols, col)
+		}
+		sort.Ints(cols)
+		var lines []string
+		for _, col := range cols {
+			name := header[col]
+			if name == "" {
+				name = columnName(col)
+			}
+			lines = append(lines, fmt.Sprintf("%s: %s", name, row[col]))
+		}
+		blocks = append(blocks, strings.Join(lines, "\n"))
+	}
+	return strings.Join(blocks, "\n\n")
+}
+
+// columnIndex 将单元格引用（如 "AB12"）转换为从0开始的列号
+func columnIndex(ref string) int {
+	col := 0
+	for _, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		col = col*26 + int(r-'A'+1)
+	}
+	return col - 1
+}
+
+// columnName 将列号转换为列字母
+func columnName(col int) string {
+	name := ""
+	for col++; col > 0; col = (col - 1) / 26 {
+		name = string(rune('A'+(col-1)%26)) + name
+	}
+	return name
+}
+
+// readZipFile 读取压缩包中的文件，解压后的大小计入 budget
+func readZipFile(zr *zip.Reader, name string, budget *archiveBudget) ([]byte, error) {
+	for _, f := range zr.File {
+		if f.Name != name {
+			continue
+		}
+		//声明的大小已经超过配额时不必解压
+		if budget.maxBytes > 0 && f.UncompressedSize64 > uint64(budget.remaining) {
+			return nil, fmt.Errorf("%s 解压后大小超过上限 %d 字节", name, budget.maxBytes)
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("打开 %s 失败：%v", name, err)
+		}
+		defer rc.Close()
+		data, err := budget.read(rc)
+		if err != nil {
+			return nil, fmt.Errorf("读取 %s 失败：%v", name, err)
+		}
+		return data, nil
+	}
+	return nil, fmt.Errorf("缺少 %s", name)
+}
+
+// xmlAttr 读取XML属性，忽略命名空间
+func xmlAttr(el xml.StartElement, local string) string {
+	for _, a := range el.Attr {
+		if a.Name.Local == local {
+			return a.Value
+		}
+	}
+	return ""
+}
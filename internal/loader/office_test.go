@@ -0,0 +1,106 @@
+package loader
+
+import (
+	"archive/zip"
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// zipFiles 在内存中生成zip文件
+func zipFiles(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestLoadXLSXDates(t *testing.T) {
+	files := map[string]string{
+		"xl/workbook.xml": `<workbook><sheets><sheet name="订单"/></sheets></workbook>`,
+		"xl/styles.xml": `<styleSheet>
+<numFmts><numFmt numFmtId="164" formatCode="yyyy/mm/dd"/><numFmt numFmtId="165" formatCode="0.00&quot; day&quot;"/></numFmts>
+<cellXfs><xf numFmtId="0"/><xf numFmtId="14"/><xf numFmtId="164"/><xf numFmtId="165"/><xf numFmtId="22"/><xf numFmtId="20"/></cellXfs>
+</styleSheet>`,
+		"xl/worksheets/sheet1.xml": `<worksheet><sheetData>
+<row><c r="A1" t="inlineStr"><is><t>数量</t></is></c><c r="B1" t="inlineStr"><is><t>下单</t></is></c><c r="C1" t="inlineStr"><is><t>发货</t></is></c><c r="D1" t="inlineStr"><is><t>时长</t></is></c><c r="E1" t="inlineStr"><is><t>签收</t></is></c><c r="F1" t="inlineStr"><is><t>时间</t></is></c></row>
+<row><c r="A2" s="0"><v>45292</v></c><c r="B2" s="1"><v>45292</v></c><c r="C2" s="2"><v>45293</v></c><c r="D2" s="3"><v>1.5</v></c><c r="E2" s="4"><v>45294.5</v></c><c r="F2" s="5"><v>0.75</v></c></row>
+</sheetData></worksheet>`,
+	}
+	docs, err := LoadXLSX(Source{Name: "orders.xlsx", Data: zipFiles(t, files)})
+	if err != nil {
+		t.Fatalf("LoadXLSX() error = %v", err)
+	}
+	want := "数量: 45292\n下单: 2024-01-01\n发货: 2024-01-02\n时长: 1.5\n签收: 2024-01-03 12:00:00\n时间: 18:00:00"
+	if got := docs[0].Content; got != want {
+		t.Errorf("LoadXLSX() content = %q, want %q", got, want)
+	}
+}
+
+func TestXLSXDatesFormat(t *testing.T) {
+	tests := []struct {
+		value    string
+		date1904 bool
+		want     string
+	}{
+		{value: "1", want: "1899-12-31"},
+		{value: "45292", want: "2024-01-01"},
+		{value: "0", date1904: true, want: "1904-01-01"},
+		{value: "45292.25", want: "2024-01-01 06:00:00"},
+		{value: "abc", want: "abc"},
+	}
+	for _, tt := range tests {
+		if got := (xlsxDates{date1904: tt.date1904}).format(tt.value); got != tt.want {
+			t.Errorf("format(%q, date1904=%v) = %q, want %q", tt.value, tt.date1904, got, tt.want)
+		}
+	}
+}
+
+func TestReadZipFileBudget(t *testing.T) {
+	data := zipFiles(t, map[string]string{
+		"word/document.xml": strings.Repeat("a", 2048),
+		"word/styles.xml":   strings.Repeat("b", 600),
+	})
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		file    string
+		budget  *archiveBudget
+		wantErr bool
+	}{
+		{name: "配额内", file: "word/styles.xml", budget: newArchiveBudget(1024, 0)},
+		{name: "超过配额", file: "word/document.xml", budget: newArchiveBudget(1024, 0), wantErr: true},
+		{name: "不限制", file: "word/document.xml", budget: newArchiveBudget(0, 0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := readZipFile(zr, tt.file, tt.budget)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("readZipFile(%s) error = %v, wantErr %v", tt.file, err, tt.wantErr)
+			}
+		})
+	}
+	//同一配额读取多个文件时累计计算
+	budget := newArchiveBudget(1024, 0)
+	if _, err := readZipFile(zr, "word/styles.xml", budget); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readZipFile(zr, "word/styles.xml", budget); err == nil {
+		t.Errorf("second read within a shared budget succeeded, want size limit error")
+	}
+}
@@ -0,0 +1,70 @@
+package loader
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"mini-rag-go/internal/models"
+	"strconv"
+	"strings"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// LoadPDF 按页提取PDF文本，每一页生成一个文档
+func LoadPDF(src Source) ([]models.Document, error) {
+	reader, err := pdf.NewReader(bytes.NewReader(src.Data), int64(len(src.Data)))
+	if err != nil {
+		if isEncryptedErr(err) {
+			return nil, fmt.Errorf("PDF已加密，无法提取文本：%v", err)
+		}
+		return nil, fmt.Errorf("打开PDF失败：%v", err)
+	}
+
+	totalPages := reader.NumPage()
+	fonts := make(map[string]*pdf.Font)
+	var documents []models.Document
+	for i := 1; i <= totalPages; i++ {
+		text, err := extractPageText(reader, i, fonts)
+		if err != nil {
+			fmt.Printf("警告：%s 第%d页解析失败：%v\n", src.Name, i, err)
+			continue
+		}
+		if text == "" {
+			continue
+		}
+		doc := newDocument(src, fmt.Sprintf("p%d", i), text, "pdf")
+		doc.Metadata["page"] = strconv.Itoa(i)
+		doc.Metadata["pages"] = strconv.Itoa(totalPages)
+		documents = append(documents, doc)
+	}
+	if len(documents) == 0 {
+		return nil, fmt.Errorf("PDF中没有可提取的文本（可能是扫描件或纯图片），共 %d 页", totalPages)
+	}
+	return documents, nil
+}
+
+// extractPageText 提取单页文本
+func extractPageText(reader *pdf.Reader, num int, fonts map[string]*pdf.Font) (string, error) {
+	page := reader.Page(num)
+	if page.V.IsNull() || page.V.Key("Contents").IsNull() {
+		return "", nil
+	}
+	//缓存字体，避免重复解析字符映射
+	for _, name := range page.Fonts() {
+		if _, ok := fonts[name]; !ok {
+			font := page.Font(name)
+			fonts[name] = &font
+		}
+	}
+	text, err := page.GetPlainText(fonts)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(text), nil
+}
+
+// isEncryptedErr 判断是否为加密导致的错误
+func isEncryptedErr(err error) bool {
+	return errors.Is(err, pdf.ErrInvalidPassword) || strings.Contains(err.Error(), "encrypt")
+}
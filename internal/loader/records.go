@@ -0,0 +1,174 @@
+package loader
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mini-rag-go/internal/models"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// RecordMapping 结构化记录的字段映射
+type RecordMapping struct {
+	ContentFields  []string // 拼接为正文的字段，为空时使用全部字段
+	MetadataFields []string // 写入元数据的字段
+	IDField        string   // 作为稳定ID的字段，为空时使用行号
+}
+
+// RecordLoader CSV/JSONL记录加载器，每条记录生成一个文档
+type RecordLoader struct {
+	Mapping RecordMapping
+}
+
+// NewRecordLoader 创建记录加载器
+func NewRecordLoader(mapping RecordMapping) *RecordLoader {
+	return &RecordLoader{Mapping: mapping}
+}
+
+// Load 按扩展名解析CSV或JSONL
+func (l *RecordLoader) Load(src Source) ([]models.Document, error) {
+	text, enc, err := DecodeText(src.Data)
+	if err != nil {
+		return nil, err
+	}
+	var records []map[string]string
+	var fields []string
+	if strings.HasSuffix(strings.ToLower(src.Name), ".csv") {
+		records, fields, err = readCSVRecords([]byte(text))
+	} else {
+		records, fields, err = readJSONLRecords([]byte(text))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	contentFields := l.Mapping.ContentFields
+	if len(contentFields) == 0 {
+		contentFields = fields
+	}
+	var documents []models.Document
+	seen := make(map[string]bool)
+	for i, record := range records {
+		content := formatRecord(record, contentFields)
+		if content == "" {
+			continue
+		}
+		recordID := strconv.Itoa(i + 1)
+		if l.Mapping.IDField != "" {
+			recordID = strings.TrimSpace(record[l.Mapping.IDField])
+			if recordID == "" {
+				fmt.Printf("警告：%s 第%d条记录缺少ID字段 %s，已跳过\n", src.Name, i+1, l.Mapping.IDField)
+				continue
+			}
+		}
+		if seen[recordID] {
+			fmt.Printf("警告：%s 中的记录ID %s 重复，已跳过\n", src.Name, recordID)
+			continue
+		}
+		seen[recordID] = true
+
+		doc := newDocument(src, recordID, content, "record")
+		doc.Metadata["record_id"] = recordID
+		for _, field := range l.Mapping.MetadataFields {
+			if value := record[field]; value != "" {
+				doc.Metadata[field] = value
+			}
+		}
+		documents = append(documents, doc)
+	}
+	return tagEncoding(documents, enc), nil
+}
+
+// formatRecord 将指定字段拼接为正文，多个字段时使用 "字段: 值" 形式
+func formatRecord(record map[string]string, fields []string) string {
+	if len(fields) == 1 {
+		return strings.TrimSpace(record[fields[0]])
+	}
+	var lines []string
+	for _, field := range fields {
+		if value := strings.TrimSpace(record[field]); value != "" {
+			lines = append(lines, fmt.Sprintf("%s: %s", field, value))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// readCSVRecords 读取CSV，第一行为表头
+func readCSVRecords(data []byte) ([]map[string]string, []string, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err != nil {
+		return nil, nil, fmt.Errorf("读取CSV表头失败：%v", err)
+	}
+	for i := range header {
+		header[i] = strings.TrimSpace(header[i])
+	}
+	var records []map[string]string
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("解析CSV失败：%v", err)
+		}
+		record := make(map[string]string, len(header))
+		for i, value := range row {
+			if i < len(header) {
+				record[header[i]] = value
+			}
+		}
+		records = append(records, record)
+	}
+	return records, header, nil
+}
+
+// readJSONLRecords 读取JSONL，每行一个JSON对象，非字符串值转为JSON文本
+func readJSONLRecords(data []byte) ([]map[string]string, []string, error) {
+	var records []map[string]string
+	fieldSet := make(map[string]bool)
+	var fields []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var raw map[string]json.RawMessage
+		if err := json.Unmarshal(line, &raw); err != nil {
+			return nil, nil, fmt.Errorf("第%d行JSON解析失败：%v", lineNum, err)
+		}
+		record := make(map[string]string, len(raw))
+		keys := make([]string, 0, len(raw))
+		for key, value := range raw {
+			var s string
+			if err := json.Unmarshal(value, &s); err != nil && string(value) != "null" {
+				s = string(value)
+			}
+			record[key] = s
+			keys = append(keys, key)
+		}
+		//JSON对象无序，新字段按字母序追加
+		sort.Strings(keys)
+		for _, key := range keys {
+			if !fieldSet[key] {
+				fieldSet[key] = true
+				fields = append(fields, key)
+			}
+		}
+		records = append(records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, fmt.Errorf("读取JSONL失败：%v", err)
+	}
+	return records, fields, nil
+}
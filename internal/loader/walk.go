@@ -0,0 +1,124 @@
+package loader
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// WalkOptions 目录遍历选项
+type WalkOptions struct {
+	Include        []string // 包含的glob模式，为空时包含所有文件
+	Exclude        []string // 排除的glob模式，匹配的目录整体跳过
+	FollowSymlinks bool     // 是否跟随符号链接
+}
+
+// Walk 递归遍历目录，对每个匹配的文件调用 fn，rel 为相对根目录的斜杠分隔路径
+func Walk(root string, opts WalkOptions, fn func(filePath, rel string) error) error {
+	info, err := os.Stat(root)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s 不是目录", root)
+	}
+	visited := make(map[string]bool)
+	if real, err := filepath.EvalSymlinks(root); err == nil {
+		visited[real] = true
+	}
+	return walkDir(root, "", opts, visited, fn)
+}
+
+// walkDir 遍历单个目录
+func walkDir(dir, relDir string, opts WalkOptions, visited map[string]bool, fn func(filePath, rel string) error) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		filePath := filepath.Join(dir, entry.Name())
+		rel := path.Join(relDir, entry.Name())
+		isDir := entry.IsDir()
+
+		if entry.Type()&os.ModeSymlink != 0 {
+			if !opts.FollowSymlinks {
+				continue
+			}
+			target, err := os.Stat(filePath)
+			if err != nil {
+				fmt.Printf("警告：无法解析符号链接 %s：%v\n", rel, err)
+				continue
+			}
+			isDir = target.IsDir()
+		}
+
+		if matchAny(opts.Exclude, rel) {
+			continue
+		}
+		if isDir {
+			//记录真实路径，避免符号链接造成的循环
+			real, err := filepath.EvalSymlinks(filePath)
+			if err != nil || visited[real] {
+				continue
+			}
+			visited[real] = true
+			if err := walkDir(filePath, rel, opts, visited, fn); err != nil {
+				return err
+			}
+			continue
+		}
+		if len(opts.Include) > 0 && !matchAny(opts.Include, rel) {
+			continue
+		}
+		if err := fn(filePath, rel); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// matchAny 判断路径是否匹配任意一个模式
+func matchAny(patterns []string, rel string) bool {
+	for _, pattern := range patterns {
+		if MatchGlob(pattern, rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchGlob 匹配斜杠分隔的相对路径，支持 "**" 匹配任意层目录；
+// 不含 "/" 的模式只匹配文件名，如 "*.pdf" 匹配任意层级下的PDF文件
+func MatchGlob(pattern, rel string) bool {
+	pattern = strings.TrimPrefix(filepath.ToSlash(pattern), "./")
+	if !strings.Contains(pattern, "/") {
+		ok, _ := path.Match(pattern, path.Base(rel))
+		return ok
+	}
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(rel, "/"))
+}
+
+// matchSegments 逐段匹配
+func matchSegments(pattern, parts []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			//"**" 可以匹配零个或多个目录
+			for i := 0; i <= len(parts); i++ {
+				if matchSegments(pattern[1:], parts[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(parts) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], parts[0]); !ok {
+			return false
+		}
+		pattern, parts = pattern[1:], parts[1:]
+	}
+	return len(parts) == 0
+}
@@ -1,5 +1,7 @@
 package models
 
+import "fmt"
+
 // Document 文档结构
 type Document struct {
 	ID        string            `json:"id"`
@@ -9,16 +11,65 @@ type Document struct {
 	Embedding []float32         `json:"embedding"`
 }
 
-// DocumentChunk 文档分块
+// DocumentChunk 文档分块，位置为左闭右开区间，含义由元数据 "offsets" 决定：
+//   - "source"：纯文本和源代码等原样加载的 UTF-8 文件，位置是源文件中的字符和字节区间（已计入BOM），
+//     可以直接用于在原文件中高亮；
+//   - "loaded"：PDF 按页拆分，Markdown、HTML、DOCX 按章节拆分后加上标题并去掉行内标记，
+//     转码的文件长度也会变化，位置只对应加载后的 Document.Content，定位原文时使用 page、section 等元数据
 type DocumentChunk struct {
 	Document
-	ChunkIndex int `json:"chunk_index"`
-	StartPos   int `json:"start_pos"`
-	EndPos     int `json:"end_pos"`
+	ChunkIndex int    `json:"chunk_index"`
+	StartPos   int    `json:"start_pos"`           // 起始位置（字符）
+	EndPos     int    `json:"end_pos"`             // 结束位置（字符）
+	StartByte  int    `json:"start_byte"`          // 起始位置（UTF-8字节）
+	EndByte    int    `json:"end_byte"`            // 结束位置（UTF-8字节）
+	ParentID   string `json:"parent_id,omitempty"` // 所属父块的ID，只有父子分块时才有
+	Header     string `json:"header,omitempty"`    // 生成嵌入时加在内容前的上下文标题，不用于显示
+	// TableHeader 表格按行切分后，除第一块外每块重复的表头；不在 Content 和位置区间内，
+	// 显示和生成嵌入时加在内容前面
+	TableHeader string `json:"table_header,omitempty"`
+}
+
+// Text 返回用于显示和生成回答的文本：表格续块带上表头
+func (c DocumentChunk) Text() string {
+	if c.TableHeader == "" {
+		return c.Content
+	}
+	return c.TableHeader + "\n" + c.Content
+}
+
+// EmbeddingText 返回用于生成嵌入的文本：有上下文标题时放在内容前面
+func (c DocumentChunk) EmbeddingText() string {
+	if c.Header == "" {
+		return c.Text()
+	}
+	return c.Header + "\n" + c.Text()
 }
 
 // SearchResult 搜索结果
 type SearchResult struct {
-	Document Document
-	Score    float64
+	DocumentChunk
+	Score float64
+}
+
+// SourceLabel 返回用于引用的来源标签，如 "refund_policy.pdf p.3"、"guide.md - 退款政策 > 退款流程"、"main.go:120-158"；
+// 有页面标题时用标题代替文件名
+func (d Document) SourceLabel() string {
+	label := d.Filename
+	if title := d.Metadata["title"]; title != "" {
+		label = title
+	}
+	if page := d.Metadata["page"]; page != "" {
+		label = fmt.Sprintf("%s p.%s", label, page)
+	}
+	if sheet := d.Metadata["sheet"]; sheet != "" {
+		label = fmt.Sprintf("%s - %s", label, sheet)
+	}
+	if section := d.Metadata["section"]; section != "" {
+		label = fmt.Sprintf("%s - %s", label, section)
+	}
+	if start, end := d.Metadata["start_line"], d.Metadata["end_line"]; start != "" && end != "" {
+		label = fmt.Sprintf("%s:%s-%s", label, start, end)
+	}
+	return label
 }
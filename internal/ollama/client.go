@@ -109,7 +109,7 @@ func BuildRAGPrompt(query string, context []models2.Document) string {
 	// 添加检索到的上下文
 	contextBuilder.WriteString("相关文档内容：\n")
 	for i, doc := range context {
-		contextBuilder.WriteString(fmt.Sprintf("【来源%d:%s】\n", i+1, doc.Filename))
+		contextBuilder.WriteString(fmt.Sprintf("【来源%d:%s】\n", i+1, doc.SourceLabel()))
 		contextBuilder.WriteString(doc.Content)
 		contextBuilder.WriteString("\n\n")
 	}
@@ -128,7 +128,7 @@ func BuildRefundPrompt(query string, context []models2.Document) string {
 	contextBuilder.WriteString("相关文档信息：\n")
 
 	for i, doc := range context {
-		contextBuilder.WriteString(fmt.Sprintf("===== 文档 %d ======\n", i+1))
+		contextBuilder.WriteString(fmt.Sprintf("===== 文档 %d：%s ======\n", i+1, doc.SourceLabel()))
 		contextBuilder.WriteString(doc.Content)
 		contextBuilder.WriteString("\n\n")
 	}
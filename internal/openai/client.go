@@ -0,0 +1,167 @@
+package openai
+
+import (
+	"context"
+	"fmt"
+	"mini-rag-go/internal/models"
+	"mini-rag-go/internal/vector"
+	"sort"
+	"sync"
+	"time"
+
+	goopenai "github.com/sashabaranov/go-openai"
+)
+
+// DefaultBatchSize 每次请求嵌入接口的默认文本数
+const DefaultBatchSize = 64
+
+// newAPIClient 创建兼容 OpenAI 接口的客户端，vLLM、LM Studio、llama.cpp server 等都可以使用
+func newAPIClient(baseURL, apiKey string) *goopenai.Client {
+	cfg := goopenai.DefaultConfig(apiKey)
+	if baseURL != "" {
+		cfg.BaseURL = baseURL
+	}
+	return goopenai.NewClientWithConfig(cfg)
+}
+
+// ChatClient 兼容 OpenAI 的聊天补全客户端，作为回答生成的后端
+type ChatClient struct {
+	Model   string
+	Timeout time.Duration
+	client  *goopenai.Client
+}
+
+// NewChatClient 创建聊天客户端，baseURL 形如 http://localhost:8000/v1
+func NewChatClient(baseURL, apiKey, model string) *ChatClient {
+	return &ChatClient{
+		Model:   model,
+		Timeout: 60 * time.Second,
+		client:  newAPIClient(baseURL, apiKey),
+	}
+}
+
+// Generate 生成文本，提示词作为一条用户消息发送
+func (c *ChatClient) Generate(prompt string, options models.RequestOptions) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.Timeout)
+	defer cancel()
+	resp, err := c.client.CreateChatCompletion(ctx, goopenai.ChatCompletionRequest{
+		Model: c.Model,
+		Messages: []goopenai.ChatCompletionMessage{
+			{Role: goopenai.ChatMessageRoleUser, Content: prompt},
+		},
+		Temperature: options.Temperature,
+		TopP:        options.TopP,
+		MaxTokens:   options.NumPredict,
+	})
+	if err != nil {
+		return "", fmt.Errorf("API请求失败：%v", err)
+	}
+	if len(resp.Choices) == 0 {
+		return "", fmt.Errorf("API没有返回结果")
+	}
+	return resp.Choices[0].Message.Content, nil
+}
+
+// CheckHealth 通过模型列表接口检查服务是否可用
+func (c *ChatClient) CheckHealth() error {
+	ctx, cancel := context.WithTimeout(context.Background(), c.Timeout)
+	defer cancel()
+	if _, err := c.client.ListModels(ctx); err != nil {
+		return fmt.Errorf("无法连接到API服务：%v", err)
+	}
+	return nil
+}
+
+// Embedder 兼容 OpenAI 的嵌入客户端，实现 vector.Embedder
+type Embedder struct {
+	Model     string
+	BatchSize int
+	Timeout   time.Duration
+	client    *goopenai.Client
+
+	dimMu     sync.Mutex
+	dimension int
+}
+
+// NewEmbedder 创建嵌入客户端
+func NewEmbedder(baseURL, apiKey, model string, batchSize int) *Embedder {
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+	return &Embedder{
+		Model:     model,
+		BatchSize: batchSize,
+		Timeout:   60 * time.Second,
+		client:    newAPIClient(baseURL, apiKey),
+	}
+}
+
+// Embed 生成嵌入向量
+func (e *Embedder) Embed(text string) ([]float32, error) {
+	vectors, err := e.EmbedBatch([]string{text})
+	if err != nil {
+		return nil, err
+	}
+	return vectors[0], nil
+}
+
+// EmbedBatch 批量生成嵌入向量，按 BatchSize 分批请求，结果顺序与输入一致
+func (e *Embedder) EmbedBatch(texts []string) ([][]float32, error) {
+	vectors := make([][]float32, 0, len(texts))
+	for start := 0; start < len(texts); start += e.BatchSize {
+		end := min(start+e.BatchSize, len(texts))
+		batch, err := e.request(texts[start:end])
+		if err != nil {
+			return nil, err
+		}
+		vectors = append(vectors, batch...)
+	}
+	return vectors, nil
+}
+
+// request 发送一次嵌入请求
+func (e *Embedder) request(texts []string) ([][]float32, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), e.Timeout)
+	defer cancel()
+	resp, err := e.client.CreateEmbeddings(ctx, goopenai.EmbeddingRequestStrings{
+		Input: texts,
+		Model: goopenai.EmbeddingModel(e.Model),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("嵌入API请求失败：%v", err)
+	}
+	if len(resp.Data) != len(texts) {
+		return nil, fmt.Errorf("嵌入数量不匹配：请求 %d 条，返回 %d 条", len(texts), len(resp.Data))
+	}
+	//按 index 排序，部分服务返回的顺序与请求不同
+	sort.Slice(resp.Data, func(i, j int) bool {
+		return resp.Data[i].Index < resp.Data[j].Index
+	})
+	vectors := make([][]float32, len(resp.Data))
+	for i, data := range resp.Data {
+		vectors[i] = data.Embedding
+	}
+	return vectors, nil
+}
+
+// ID 返回嵌入器标识
+func (e *Embedder) ID() string {
+	return "openai:" + e.Model
+}
+
+// Fingerprint 返回嵌入器指纹，兼容服务返回的向量不一定归一化
+func (e *Embedder) Fingerprint() vector.Fingerprint {
+	return vector.Fingerprint{Name: "openai", Model: e.Model, Dimension: e.Dimension(), Version: "1"}
+}
+
+// Dimension 返回向量维度，请求一次接口获取后缓存；失败时返回 0，下次调用重试
+func (e *Embedder) Dimension() int {
+	e.dimMu.Lock()
+	defer e.dimMu.Unlock()
+	if e.dimension == 0 {
+		if vector, err := e.Embed("dimension"); err == nil {
+			e.dimension = len(vector)
+		}
+	}
+	return e.dimension
+}
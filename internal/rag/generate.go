@@ -5,22 +5,37 @@ import (
 	"mini-rag-go/internal/config"
 	models2 "mini-rag-go/internal/models"
 	"mini-rag-go/internal/ollama"
+	"mini-rag-go/internal/tokenizer"
 	"mini-rag-go/internal/utils"
 	"strings"
 )
 
+// Backend 回答生成的后端，如 Ollama 或兼容 OpenAI 的服务
+type Backend interface {
+	Generate(prompt string, options models2.RequestOptions) (string, error)
+	CheckHealth() error
+}
+
 // Generator 回答生成器
 type Generator struct {
-	ollamaClient *ollama.Client
+	backend      Backend
+	tokenizer    tokenizer.Tokenizer
+	promptBudget int
 }
 
 // NewGenerator 创建生成器
-func NewGenerator(client *ollama.Client) *Generator {
+func NewGenerator(backend Backend) *Generator {
 	return &Generator{
-		ollamaClient: client,
+		backend: backend,
 	}
 }
 
+// SetPromptBudget 设置提示词的token预算，超出预算时从相似度最低的文档开始舍弃
+func (g *Generator) SetPromptBudget(tok tokenizer.Tokenizer, maxTokens int) {
+	g.tokenizer = tok
+	g.promptBudget = maxTokens
+}
+
 // GenerateAnswer 生成回答
 func (g *Generator) GenerateAnswer(query string, searchResults []models2.SearchResult) (string, error) {
 	if len(searchResults) == 0 {
@@ -30,14 +45,14 @@ func (g *Generator) GenerateAnswer(query string, searchResults []models2.SearchR
 	documents := make([]models2.Document, len(searchResults))
 	for i, result := range searchResults {
 		documents[i] = result.Document
+		documents[i].Content = result.Text()
 	}
 	// 根据查询类型选择提示词模板
-	var prompt string
+	buildPrompt := ollama.BuildRAGPrompt
 	if strings.Contains(query, "退款") || strings.Contains(query, "退货") {
-		prompt = ollama.BuildRefundPrompt(query, documents)
-	} else {
-		prompt = ollama.BuildRAGPrompt(query, documents)
+		buildPrompt = ollama.BuildRefundPrompt
 	}
+	prompt := g.fitPrompt(query, documents, buildPrompt)
 	// 设置生成选项
 	options := models2.RequestOptions{
 		Temperature: config.Global.LLM.Temperature,
@@ -45,8 +60,8 @@ func (g *Generator) GenerateAnswer(query string, searchResults []models2.SearchR
 		TopK:        40,
 		NumPredict:  config.Global.LLM.MaxTokens,
 	}
-	//调用 Ollama生成回答
-	answer, err := g.ollamaClient.Generate(prompt, options)
+	//调用后端生成回答
+	answer, err := g.backend.Generate(prompt, options)
 	if err != nil {
 		return "", fmt.Errorf("生成回答失败：%v", err)
 	}
@@ -55,6 +70,29 @@ func (g *Generator) GenerateAnswer(query string, searchResults []models2.SearchR
 	return answer, err
 }
 
+// fitPrompt 构建不超过token预算的提示词，检索结果按相似度排序，超出时舍弃末尾的文档
+func (g *Generator) fitPrompt(query string, documents []models2.Document, build func(string, []models2.Document) string) string {
+	prompt := build(query, documents)
+	if g.tokenizer == nil || g.promptBudget <= 0 {
+		return prompt
+	}
+	for n := len(documents); n > 1; n-- {
+		prompt = build(query, documents[:n])
+		if tokens := g.tokenizer.Count(prompt); tokens <= g.promptBudget {
+			if n < len(documents) {
+				fmt.Printf("提示词超出 %d token 预算，只使用前 %d 个文档\n", g.promptBudget, n)
+			}
+			return prompt
+		}
+	}
+	//至少保留一个文档
+	prompt = build(query, documents[:1])
+	if tokens := g.tokenizer.Count(prompt); tokens > g.promptBudget {
+		fmt.Printf("警告：单个文档的提示词已有 %d token，超出 %d 的预算\n", tokens, g.promptBudget)
+	}
+	return prompt
+}
+
 // GenerateAnswerWithFallback 带降级的回答生成
 func (g *Generator) GenerateAnswerWithFallback(query string, searchResults []models2.SearchResult) string {
 	// 首先尝试使用 LLM生成
@@ -81,7 +119,7 @@ func generateRuleBaseAnswer(query string, searchResults []models2.SearchResult)
 	if strings.Contains(lowerQuery, "流程") || strings.Contains(lowerQuery, "步骤") || strings.Contains(lowerQuery, "怎么") || strings.Contains(lowerQuery, "如何") {
 		answer.WriteString("根据文档内容，相关流程如下：\n\n")
 		for i, result := range searchResults {
-			content := extractProcessSteps(result.Document.Content)
+			content := extractProcessSteps(result.Text())
 			if content != "" {
 				answer.WriteString(fmt.Sprintf("%d. %s\n", i+1,
 					utils.TruncateText(content, 200)))
@@ -90,7 +128,7 @@ func generateRuleBaseAnswer(query string, searchResults []models2.SearchResult)
 	} else if strings.Contains(lowerQuery, "时间") || strings.Contains(lowerQuery, "多久") {
 		answer.WriteString("根据文档中的时间信息：\n\n")
 		for _, result := range searchResults {
-			content := extractTimeInfo(result.Document.Content)
+			content := extractTimeInfo(result.Text())
 			if content != "" {
 				answer.WriteString(fmt.Sprintf("• %s\n", content))
 			}
@@ -101,7 +139,7 @@ func generateRuleBaseAnswer(query string, searchResults []models2.SearchResult)
 
 		for i, result := range searchResults {
 			answer.WriteString(fmt.Sprintf("%d. %s\n\n", i+1,
-				utils.TruncateText(result.Document.Content, 150)))
+				utils.TruncateText(result.Text(), 150)))
 		}
 	}
 	if answer.Len() == 0 {
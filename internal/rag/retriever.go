@@ -1,150 +1,183 @@
 package rag
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"mini-rag-go/internal/chunker"
+	"mini-rag-go/internal/loader"
 	"mini-rag-go/internal/models"
 	"mini-rag-go/internal/store"
-	"mini-rag-go/internal/utils"
 	"os"
 	"path/filepath"
-	"strings"
+	"sort"
+	"strconv"
 )
 
 // Retriever 检索器
 type Retriever struct {
-	vectorStore  *store.VectorStore
-	chunkSize    int
-	chunkOverlap int
+	vectorStore *store.VectorStore
+	loaders     *loader.Registry
+	walkOptions loader.WalkOptions
+	chunker     chunker.Chunker
+	ingest      store.IngestOptions
 }
 
-// NewRetriever 创建检索器
+// NewRetriever 创建检索器，默认按句子分块
 func NewRetriever(store *store.VectorStore, chunkSize, chunkOverlap int) *Retriever {
+	selector, _ := chunker.NewSelector(chunker.StrategySentence, nil, chunker.Options{
+		ChunkSize:    chunkSize,
+		ChunkOverlap: chunkOverlap,
+	})
 	return &Retriever{
-		vectorStore:  store,
-		chunkSize:    chunkSize,
-		chunkOverlap: chunkOverlap,
+		vectorStore: store,
+		loaders:     loader.DefaultRegistry(),
+		chunker:     selector,
+		ingest:      defaultIngestOptions,
 	}
 }
 
-// LoadDocumentsFromDir 从目录加载文档
+// defaultIngestOptions 默认的嵌入生成参数
+var defaultIngestOptions = store.IngestOptions{Workers: 4, BatchSize: 32, Progress: printProgress}
+
+// SetIngestOptions 设置构建向量存储时生成嵌入的并发数和批大小，未指定进度回调时在终端打印进度
+func (r *Retriever) SetIngestOptions(opts store.IngestOptions) {
+	if opts.Progress == nil {
+		opts.Progress = printProgress
+	}
+	r.ingest = opts
+}
+
+// printProgress 在同一行打印嵌入进度
+func printProgress(done, total int) {
+	fmt.Printf("\r生成嵌入：%d/%d", done, total)
+	if done == total {
+		fmt.Println()
+	}
+}
+
+// Loaders 返回文档加载器注册表，可注册自定义格式
+func (r *Retriever) Loaders() *loader.Registry {
+	return r.loaders
+}
+
+// SetWalkOptions 设置目录遍历选项（包含/排除模式、符号链接）
+func (r *Retriever) SetWalkOptions(opts loader.WalkOptions) {
+	r.walkOptions = opts
+}
+
+// LoadDocumentsFromDir 递归加载目录下的文档
 func (r *Retriever) LoadDocumentsFromDir(dirPath string) ([]models.Document, error) {
 	var documents []models.Document
-	files, err := os.ReadDir(dirPath)
+	report := loader.NewReport()
+	err := loader.Walk(dirPath, r.walkOptions, func(filePath, rel string) error {
+		content, err := os.ReadFile(filePath)
+		if err != nil {
+			fmt.Printf("警告：无法读取文件：%s:%v\n", rel, err)
+			report.AddFailed(rel, err)
+			return nil
+		}
+		documents = append(documents, r.loadSource(loader.Source{
+			Name:    filepath.Base(filePath),
+			Path:    filePath,
+			RelPath: rel,
+			Data:    content,
+		}, report)...)
+		return nil
+	})
 	if err != nil {
 		return nil, fmt.Errorf("读取目录失败： %v", err)
 	}
-	for _, file := range files {
-		if file.IsDir() || !strings.HasSuffix(file.Name(), ".txt") {
-			continue
+	fmt.Println(report.Summary())
+	return documents, nil
+}
+
+// loadSource 用注册表加载单个文件，压缩包展开后逐个加载内部文件
+func (r *Retriever) loadSource(src loader.Source, report *loader.Report) []models.Document {
+	l, fileType, ok := r.loaders.Lookup(src.Name, src.Data)
+	if !ok {
+		report.AddUnknown(fileType, src.RelPath)
+		return nil
+	}
+	if expander, ok := l.(loader.Expander); ok {
+		if src.Archive != "" {
+			report.AddFailed(src.RelPath, fmt.Errorf("不支持嵌套的压缩包"))
+			return nil
 		}
-		filePath := filepath.Join(dirPath, file.Name())
-		content, err := os.ReadFile(filePath)
+		inner, err := expander.Expand(src)
 		if err != nil {
-			fmt.Printf("警告：无法读取文件安：%s:%v\n", file.Name(), err)
-			continue
+			fmt.Printf("警告：跳过压缩包 %s：%v\n", src.RelPath, err)
+			report.AddFailed(src.RelPath, err)
+			return nil
 		}
-		doc := models.Document{
-			ID:       fmt.Sprintf("%s_%d", file.Name(), len(documents)),
-			Content:  string(content),
-			Filename: file.Name(),
-			Metadata: map[string]string{
-				"filename": file.Name(),
-				"path":     filePath,
-				"type":     "text",
-			},
+		var documents []models.Document
+		for _, innerSrc := range inner {
+			documents = append(documents, r.loadSource(innerSrc, report)...)
 		}
-		documents = append(documents, doc)
+		return documents
 	}
-	return documents, nil
+	docs, err := l.Load(src)
+	if err != nil {
+		fmt.Printf("警告：跳过文件 %s：%v\n", src.RelPath, err)
+		report.AddFailed(src.RelPath, err)
+		return nil
+	}
+	report.AddLoaded(len(docs))
+	return docs
+}
+
+// SetChunker 设置分块器
+func (r *Retriever) SetChunker(c chunker.Chunker) {
+	r.chunker = c
 }
 
 // ChunkDocument 分割文档
 func (r *Retriever) ChunkDocument(doc models.Document) []models.DocumentChunk {
-	var chunks []models.DocumentChunk
-	content := doc.Content
-	runes := []rune(content)
-	totalRunes := len(runes)
-
-	if totalRunes <= r.chunkSize {
-		//文档足够小，不需要分割
-		chunk := models.DocumentChunk{
-			Document: models.Document{
-				ID:       fmt.Sprintf("%s_chunk_0", doc.ID),
-				Content:  content,
-				Filename: doc.Filename,
-				Metadata: doc.Metadata,
-			},
-			ChunkIndex: 0,
-			StartPos:   0,
-			EndPos:     totalRunes,
-		}
-		return []models.DocumentChunk{chunk}
-	}
-	//按句子分割
-	sentences := utils.SplitTextBySentences(content)
-	var currentChunk strings.Builder
-	chunkIndex := 0
-	startPos := 0
-	for i, sentence := range sentences {
-		sentenceRunes := []rune(sentence)
-
-		if currentChunk.Len()+len(sentenceRunes) > r.chunkSize && currentChunk.Len() > 0 {
-			//保存当前块
-			chunk := models.DocumentChunk{
-				Document: models.Document{
-					ID:       fmt.Sprintf("%s_chunk_%d", doc.ID, chunkIndex),
-					Content:  currentChunk.String(),
-					Filename: doc.Filename,
-					Metadata: doc.Metadata,
-				},
-				ChunkIndex: chunkIndex,
-				StartPos:   startPos,
-				EndPos:     startPos + len([]rune(currentChunk.String())),
-			}
-			chunks = append(chunks, chunk)
-			//开始新块，保留重叠部分
-			chunkIndex++
-			currentChunk.Reset()
-
-			//添加重叠的句子（从当前块末尾往回找）
-			overlapStart := i - 1
-			if overlapStart < 0 {
-				overlapStart = 0
-			}
-			for j := overlapStart; j < i; j++ {
-				overlapSentence := sentences[j]
-				if len([]rune(overlapSentence)) < r.chunkOverlap {
-					currentChunk.WriteString(overlapSentence)
-					currentChunk.WriteRune(' ')
-				}
-			}
-			startPos = chunk.EndPos - len([]rune(currentChunk.String()))
-		}
-		currentChunk.WriteString(sentence)
-		currentChunk.WriteString(" ")
-	}
-
-	//添加最后一个块
-	if currentChunk.Len() > 0 {
-		chunk := models.DocumentChunk{
-			Document: models.Document{
-				ID:       fmt.Sprintf("%s_chunk_%d", doc.ID, chunkIndex),
-				Content:  currentChunk.String(),
-				Filename: doc.Filename,
-				Metadata: doc.Metadata,
-			},
-			ChunkIndex: chunkIndex,
-			StartPos:   startPos,
-			EndPos:     startPos + len([]rune(currentChunk.String())),
-		}
-		chunks = append(chunks, chunk)
-	}
-	return chunks
+	return r.chunker.Chunk(doc)
 }
 
+// parentFanout 父子分块时多检索的子块倍数，多个子块可能属于同一个父块
+const parentFanout = 4
+
 // Retrieve 检索相关文档
 func (r *Retriever) Retrieve(query string, topK int) ([]models.SearchResult, error) {
-	return r.vectorStore.Search(query, topK)
+	return r.RetrieveWithFilter(query, topK, nil)
+}
+
+// RetrieveWithFilter 按元数据过滤后检索，如 {"from": "...", "thread_id": "..."}；
+// 存储中有父块时，命中的子块替换为去重后的父块
+func (r *Retriever) RetrieveWithFilter(query string, topK int, filter map[string]string) ([]models.SearchResult, error) {
+	if !r.vectorStore.HasParents() {
+		return r.vectorStore.SearchWithFilter(query, topK, filter)
+	}
+	children, err := r.vectorStore.SearchWithFilter(query, topK*parentFanout, filter)
+	if err != nil {
+		return nil, err
+	}
+	return r.expandParents(children, topK), nil
+}
+
+// expandParents 将子块替换为父块，同一父块只保留得分最高的一次
+func (r *Retriever) expandParents(children []models.SearchResult, topK int) []models.SearchResult {
+	seen := make(map[string]bool)
+	var results []models.SearchResult
+	for _, child := range children {
+		if len(results) == topK {
+			break
+		}
+		parent, ok := r.vectorStore.Parent(child.ParentID)
+		if !ok {
+			//没有父块的旧数据直接返回子块
+			results = append(results, child)
+			continue
+		}
+		if seen[parent.ID] {
+			continue
+		}
+		seen[parent.ID] = true
+		results = append(results, models.SearchResult{DocumentChunk: parent, Score: child.Score})
+	}
+	return results
 }
 
 // BuildVectorStore 构建向量存储
@@ -162,23 +195,14 @@ func (r *Retriever) BuildVectorStore(docsPath, storePath string) error {
 	}
 	fmt.Printf("找到 %d 个文档\n", len(documents))
 
-	//分割文档并添加到向量存储
-	totalChunks := 0
+	//分割文档，统一生成嵌入后一次写入向量存储
+	var chunks []models.DocumentChunk
 	for _, doc := range documents {
-		chunks := r.ChunkDocument(doc)
-		for _, chunk := range chunks {
-			chunkDoc := models.Document{
-				ID:       chunk.ID,
-				Content:  chunk.Content,
-				Filename: chunk.Filename,
-				Metadata: chunk.Metadata,
-			}
-			if err := r.vectorStore.AddDocument(chunkDoc); err != nil {
-				fmt.Printf("警告：添加文档块失败：%s：%v", chunk.ID, err)
-				continue
-			}
-			totalChunks++
-		}
+		chunks = append(chunks, r.chunkForIndex(doc)...)
+	}
+	totalChunks, err := r.vectorStore.AddChunks(chunks, r.ingest)
+	if err != nil {
+		return fmt.Errorf("添加文档块失败：%v", err)
 	}
 	fmt.Printf("生成 %d 个文档块\n", totalChunks)
 
@@ -189,3 +213,117 @@ func (r *Retriever) BuildVectorStore(docsPath, storePath string) error {
 	fmt.Printf("向量存储已保存到 %s\n", storePath)
 	return nil
 }
+
+// SyncVectorStore 增量更新向量存储：只重新索引新增或内容变化的文档，并移除已删除的文档
+func (r *Retriever) SyncVectorStore(docsPath, storePath string) error {
+	if _, err := os.Stat(storePath); err != nil {
+		return r.BuildVectorStore(docsPath, storePath)
+	}
+	if r.vectorStore.DocumentCount() == 0 {
+		if err := r.vectorStore.Load(storePath); err != nil {
+			return fmt.Errorf("加载向量存储失败：%v", err)
+		}
+	}
+	documents, err := r.LoadDocumentsFromDir(docsPath)
+	if err != nil {
+		return fmt.Errorf("加载文档失败：%v", err)
+	}
+	hashes := r.vectorStore.SourceHashes()
+	if len(hashes) == 0 && r.vectorStore.DocumentCount() > 0 {
+		//旧版本存储没有记录源文档信息，无法增量更新
+		fmt.Println("向量存储缺少源文档信息，重新构建...")
+		r.vectorStore.Clear()
+	}
+
+	current := make(map[string]bool, len(documents))
+	added, updated, removed := 0, 0, 0
+	var chunks []models.DocumentChunk
+	for _, doc := range documents {
+		current[doc.ID] = true
+		oldHash, exists := hashes[doc.ID]
+		if exists && oldHash == documentHash(doc) {
+			continue
+		}
+		if exists {
+			r.vectorStore.RemoveDocument(doc.ID)
+			updated++
+		} else {
+			added++
+		}
+		chunks = append(chunks, r.chunkForIndex(doc)...)
+	}
+	if _, err := r.vectorStore.AddChunks(chunks, r.ingest); err != nil {
+		return fmt.Errorf("添加文档块失败：%v", err)
+	}
+	for docID := range hashes {
+		if !current[docID] {
+			r.vectorStore.RemoveDocument(docID)
+			removed++
+		}
+	}
+	fmt.Printf("增量更新完成：新增 %d，更新 %d，删除 %d 个文档\n", added, updated, removed)
+	if added+updated+removed == 0 {
+		return nil
+	}
+	if err := r.vectorStore.Save(storePath); err != nil {
+		return fmt.Errorf("保存向量存储失败：%v", err)
+	}
+	fmt.Printf("向量存储已保存到 %s\n", storePath)
+	return nil
+}
+
+// chunkForIndex 分割文档，返回待加入向量存储的文档块；父子分块时父块直接存入
+func (r *Retriever) chunkForIndex(doc models.Document) []models.DocumentChunk {
+	if doc.Metadata == nil {
+		doc.Metadata = make(map[string]string)
+	}
+	//记录源文档ID和内容哈希，供增量更新使用
+	doc.Metadata["content_hash"] = documentHash(doc)
+	doc.Metadata["doc_id"] = doc.ID
+	//加载器没有标记为源文件位置的，位置只对应加载后的文档内容
+	if doc.Metadata["offsets"] != "source" {
+		doc.Metadata["offsets"] = "loaded"
+	}
+
+	h, ok := r.chunker.(chunker.Hierarchical)
+	if !ok {
+		return toSourceOffsets(r.ChunkDocument(doc))
+	}
+	parents, children := h.Split(doc)
+	r.vectorStore.AddParents(toSourceOffsets(parents))
+	return toSourceOffsets(children)
+}
+
+// toSourceOffsets 位置可以回指源文件的文档块，加上加载时去掉的BOM的长度
+func toSourceOffsets(chunks []models.DocumentChunk) []models.DocumentChunk {
+	for i := range chunks {
+		metadata := chunks[i].Metadata
+		if metadata["offsets"] != "source" {
+			continue
+		}
+		runeOffset, _ := strconv.Atoi(metadata["source_rune_offset"])
+		byteOffset, _ := strconv.Atoi(metadata["source_byte_offset"])
+		chunks[i].StartPos += runeOffset
+		chunks[i].EndPos += runeOffset
+		chunks[i].StartByte += byteOffset
+		chunks[i].EndByte += byteOffset
+	}
+	return chunks
+}
+
+// documentHash 计算文档内容和元数据的哈希，用于判断文档是否变化
+func documentHash(doc models.Document) string {
+	h := sha256.New()
+	h.Write([]byte(doc.Content))
+	keys := make([]string, 0, len(doc.Metadata))
+	for key := range doc.Metadata {
+		if key != "content_hash" && key != "doc_id" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Fprintf(h, "\x00%s=%s", key, doc.Metadata[key])
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
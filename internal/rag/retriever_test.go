@@ -0,0 +1,216 @@
+package rag
+
+import (
+	"mini-rag-go/internal/chunker"
+	"mini-rag-go/internal/store"
+	"mini-rag-go/internal/vector"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"testing"
+)
+
+// writeDocs 写入文档目录，内容为空的文件被删除
+func writeDocs(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if content == "" {
+			if err := os.Remove(path); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// newTestRetriever 创建使用本地嵌入器的检索器
+func newTestRetriever(t *testing.T, header string) (*Retriever, *store.VectorStore) {
+	t.Helper()
+	vs := store.NewVectorStore(vector.NewSimpleEmbedder(32))
+	if header != "" {
+		if err := vs.SetHeaderTemplate(header); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ingest := store.IngestOptions{Workers: 2, BatchSize: 4, Progress: func(done, total int) {}}
+	vs.SetMismatchPolicy(store.MismatchReembed, ingest)
+	r := NewRetriever(vs, 200, 0)
+	r.SetIngestOptions(ingest)
+	return r, vs
+}
+
+// sourceIDs 返回存储中的源文档ID
+func sourceIDs(vs *store.VectorStore) []string {
+	var ids []string
+	for id := range vs.SourceHashes() {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func TestSyncVectorStore(t *testing.T) {
+	tests := []struct {
+		name    string
+		changes map[string]string
+		header  string
+		want    []string
+		wantHit string
+	}{
+		{
+			name:    "没有变化",
+			want:    []string{"refund.txt", "shipping.txt"},
+			wantHit: "退款需在七天内申请。",
+		},
+		{
+			name:    "新增和修改",
+			changes: map[string]string{"refund.txt": "退款需在十五天内申请。", "invoice.txt": "发票随包裹寄出。"},
+			want:    []string{"invoice.txt", "refund.txt", "shipping.txt"},
+			wantHit: "退款需在十五天内申请。",
+		},
+		{
+			name:    "删除",
+			changes: map[string]string{"shipping.txt": ""},
+			want:    []string{"refund.txt"},
+			wantHit: "退款需在七天内申请。",
+		},
+		{
+			name:    "块标题模板变化时重新生成嵌入",
+			header:  "文档：{{.Filename}}",
+			want:    []string{"refund.txt", "shipping.txt"},
+			wantHit: "退款需在七天内申请。",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			docsPath := filepath.Join(dir, "docs")
+			storePath := filepath.Join(dir, "store.json")
+			if err := os.Mkdir(docsPath, 0755); err != nil {
+				t.Fatal(err)
+			}
+			writeDocs(t, docsPath, map[string]string{
+				"refund.txt":   "退款需在七天内申请。",
+				"shipping.txt": "运费由买家承担。",
+			})
+			r, _ := newTestRetriever(t, "")
+			if err := r.BuildVectorStore(docsPath, storePath); err != nil {
+				t.Fatal(err)
+			}
+
+			writeDocs(t, docsPath, tt.changes)
+			r, vs := newTestRetriever(t, tt.header)
+			if err := r.SyncVectorStore(docsPath, storePath); err != nil {
+				t.Fatalf("SyncVectorStore() error = %v", err)
+			}
+			if got := sourceIDs(vs); !slices.Equal(got, tt.want) {
+				t.Errorf("documents after sync = %v, want %v", got, tt.want)
+			}
+			results, err := r.Retrieve("退款申请", 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(results) == 0 || results[0].Content != tt.wantHit {
+				t.Errorf("Retrieve() = %v, want %q", results, tt.wantHit)
+			}
+			if tt.header != "" && results[0].Header != "文档：refund.txt" {
+				t.Errorf("header = %q, want it rendered with the current template", results[0].Header)
+			}
+
+			//同步结果已保存，重新加载后内容一致
+			_, reloaded := newTestRetriever(t, tt.header)
+			if err := reloaded.Load(storePath); err != nil {
+				t.Fatal(err)
+			}
+			if got := sourceIDs(reloaded); !slices.Equal(got, tt.want) {
+				t.Errorf("documents after reload = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetrieveWithFilterParents(t *testing.T) {
+	dir := t.TempDir()
+	docsPath := filepath.Join(dir, "docs")
+	storePath := filepath.Join(dir, "store.json")
+	if err := os.Mkdir(docsPath, 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeDocs(t, docsPath, map[string]string{
+		"refund.txt":   "退款需在七天内申请。退款原路退回。退款三天到账。发票随包裹寄出。",
+		"shipping.txt": "运费由买家承担。退款时运费不退。",
+	})
+	r, vs := newTestRetriever(t, "")
+	r.SetChunker(chunker.NewParentChildChunker(chunker.NewSentenceChunker(20, 0), chunker.NewSentenceChunker(10, 0)))
+	if err := r.BuildVectorStore(docsPath, storePath); err != nil {
+		t.Fatal(err)
+	}
+	if !vs.HasParents() {
+		t.Fatal("parents were not stored")
+	}
+
+	tests := []struct {
+		name   string
+		filter map[string]string
+		topK   int
+		want   []string
+	}{
+		{name: "返回父块", topK: 3},
+		{name: "按来源过滤", filter: map[string]string{"doc_id": "shipping.txt"}, topK: 3, want: []string{"shipping.txt"}},
+		{name: "不超过topK", topK: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := r.RetrieveWithFilter("退款", tt.topK, tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(results) == 0 || len(results) > tt.topK {
+				t.Fatalf("RetrieveWithFilter() = %d results, want 1..%d", len(results), tt.topK)
+			}
+			seen := make(map[string]bool)
+			for _, result := range results {
+				if result.ParentID != "" {
+					t.Errorf("result %s is a child chunk", result.ID)
+				}
+				if _, ok := vs.Parent(result.ID); !ok {
+					t.Errorf("result %s is not a stored parent", result.ID)
+				}
+				if seen[result.ID] {
+					t.Errorf("parent %s returned twice", result.ID)
+				}
+				seen[result.ID] = true
+				if tt.want != nil && !slices.Contains(tt.want, result.Metadata["doc_id"]) {
+					t.Errorf("result from %s, want only %v", result.Metadata["doc_id"], tt.want)
+				}
+			}
+		})
+	}
+
+	//删除源文档后父块一起删除
+	removed, err := r.RetrieveWithFilter("运费", 3, map[string]string{"doc_id": "shipping.txt"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeDocs(t, docsPath, map[string]string{"shipping.txt": ""})
+	if err := r.SyncVectorStore(docsPath, storePath); err != nil {
+		t.Fatal(err)
+	}
+	for _, parent := range removed {
+		if _, ok := vs.Parent(parent.ID); ok {
+			t.Errorf("parent %s of a removed document is still stored", parent.ID)
+		}
+	}
+	results, err := r.RetrieveWithFilter("运费", 3, map[string]string{"doc_id": "shipping.txt"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 0 {
+		t.Errorf("chunks of a removed document were returned: %v", results)
+	}
+}
@@ -0,0 +1,62 @@
+package store
+
+import (
+	"fmt"
+	"mini-rag-go/internal/models"
+	"strings"
+	"text/template"
+)
+
+// DefaultHeaderTemplate 默认的块标题模板：文件名、文档标题和章节路径
+const DefaultHeaderTemplate = "文档：{{.Filename}}{{if .Title}}（{{.Title}}）{{end}}{{if .Section}}\n章节：{{.Section}}{{end}}"
+
+// headerData 块标题模板可以使用的字段
+type headerData struct {
+	Filename string
+	Title    string
+	Section  string
+	Page     string
+	Sheet    string
+	Metadata map[string]string
+}
+
+// SetHeaderTemplate 设置块标题模板（text/template 语法），生成嵌入前把标题加在块内容前面，
+// 让脱离上下文的文档块也带上所属文档的信息；模板为空时使用 DefaultHeaderTemplate。
+// 模板原文随存储一起保存，加载时模板与保存时不同按嵌入器不一致处理
+func (vs *VectorStore) SetHeaderTemplate(text string) error {
+	if text == "" {
+		text = DefaultHeaderTemplate
+	}
+	tmpl, err := template.New("header").Parse(text)
+	if err != nil {
+		return fmt.Errorf("解析块标题模板失败：%v", err)
+	}
+	vs.mu.Lock()
+	defer vs.mu.Unlock()
+	vs.headerTemplate = tmpl
+	vs.headerText = text
+	return nil
+}
+
+// renderHeader 按模板生成块标题，去掉空行
+func renderHeader(tmpl *template.Template, chunk models.DocumentChunk) (string, error) {
+	var sb strings.Builder
+	err := tmpl.Execute(&sb, headerData{
+		Filename: chunk.Filename,
+		Title:    chunk.Metadata["title"],
+		Section:  chunk.Metadata["section"],
+		Page:     chunk.Metadata["page"],
+		Sheet:    chunk.Metadata["sheet"],
+		Metadata: chunk.Metadata,
+	})
+	if err != nil {
+		return "", fmt.Errorf("生成块标题失败：%v", err)
+	}
+	var lines []string
+	for _, line := range strings.Split(sb.String(), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n"), nil
+}
@@ -0,0 +1,142 @@
+package store
+
+import (
+	"fmt"
+	"mini-rag-go/internal/models"
+	"mini-rag-go/internal/vector"
+	"sync"
+)
+
+// IngestOptions 批量写入的参数
+type IngestOptions struct {
+	Workers   int                   // 并发生成嵌入的协程数
+	BatchSize int                   // 每次交给嵌入器的文本数
+	Progress  func(done, total int) // 每完成一批调用一次，可为 nil
+}
+
+// embedJob 一批待生成嵌入的文本，first 为第一条文本在全部文本中的下标
+type embedJob struct {
+	first int
+	texts []string
+}
+
+// AddChunks 批量添加文档块：多个协程分批生成嵌入，期间不持有存储的锁，全部完成后一次性写入。
+// 生成嵌入失败的块会被跳过，返回成功添加的数量
+func (vs *VectorStore) AddChunks(chunks []models.DocumentChunk, opts IngestOptions) (int, error) {
+	if opts.Workers <= 0 {
+		opts.Workers = 1
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 32
+	}
+	//每个块的正文和问题（如果有）各占一条文本
+	var texts []string
+	contentIndex := make([]int, len(chunks))
+	questionIndex := make([]int, len(chunks))
+	for i := range chunks {
+		chunk, err := vs.prepareChunk(chunks[i])
+		if err != nil {
+			return 0, err
+		}
+		chunks[i] = chunk
+		contentIndex[i] = len(texts)
+		texts = append(texts, chunk.EmbeddingText())
+		questionIndex[i] = -1
+		if question := chunk.Metadata["question"]; question != "" {
+			questionIndex[i] = len(texts)
+			texts = append(texts, question)
+		}
+	}
+
+	vectors := vs.embedAll(texts, opts)
+
+	added := make([]models.DocumentChunk, 0, len(chunks))
+	addedVectors := make([][]float32, 0, len(chunks))
+	addedQuestions := make([][]float32, 0, len(chunks))
+	for i, chunk := range chunks {
+		v := vectors[contentIndex[i]]
+		var q []float32
+		if questionIndex[i] >= 0 {
+			q = vectors[questionIndex[i]]
+		}
+		if v == nil || questionIndex[i] >= 0 && q == nil {
+			fmt.Printf("警告：添加文档块失败：%s：生成嵌入失败\n", chunk.ID)
+			continue
+		}
+		added = append(added, chunk)
+		addedVectors = append(addedVectors, v)
+		addedQuestions = append(addedQuestions, q)
+	}
+
+	vs.mu.Lock()
+	defer vs.mu.Unlock()
+	vs.documents = append(vs.documents, added...)
+	vs.vectors = append(vs.vectors, addedVectors...)
+	vs.questionVectors = append(vs.questionVectors, addedQuestions...)
+	return len(added), nil
+}
+
+// embedAll 用协程池分批生成嵌入，结果与 texts 一一对应，失败的为 nil
+func (vs *VectorStore) embedAll(texts []string, opts IngestOptions) [][]float32 {
+	vectors := make([][]float32, len(texts))
+	jobs := make(chan embedJob)
+	var wg sync.WaitGroup
+	var progressMu sync.Mutex
+	done := 0
+	for w := 0; w < opts.Workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				//各批写入 vectors 中互不重叠的区间，不需要加锁
+				copy(vectors[job.first:], vs.embedBatch(job.texts))
+				if opts.Progress != nil {
+					progressMu.Lock()
+					done += len(job.texts)
+					opts.Progress(done, len(texts))
+					progressMu.Unlock()
+				}
+			}
+		}()
+	}
+	for first := 0; first < len(texts); first += opts.BatchSize {
+		end := min(first+opts.BatchSize, len(texts))
+		jobs <- embedJob{first: first, texts: texts[first:end]}
+	}
+	close(jobs)
+	wg.Wait()
+	return vectors
+}
+
+// embedBatch 生成一批嵌入；整批失败时逐条重试，只有出错的那条为 nil
+func (vs *VectorStore) embedBatch(texts []string) [][]float32 {
+	vectors, err := vector.BatchEmbed(vs.embedder, texts)
+	if err == nil && len(vectors) == len(texts) {
+		return vectors
+	}
+	vectors = make([][]float32, len(texts))
+	for i, text := range texts {
+		v, err := vs.embedder.Embed(text)
+		if err != nil {
+			fmt.Printf("警告：生成嵌入失败：%v\n", err)
+			continue
+		}
+		vectors[i] = v
+	}
+	return vectors
+}
+
+// prepareChunk 生成嵌入前的准备：按模板加上上下文标题
+func (vs *VectorStore) prepareChunk(chunk models.DocumentChunk) (models.DocumentChunk, error) {
+	vs.mu.RLock()
+	tmpl := vs.headerTemplate
+	vs.mu.RUnlock()
+	if tmpl != nil && chunk.Header == "" {
+		header, err := renderHeader(tmpl, chunk)
+		if err != nil {
+			return chunk, err
+		}
+		chunk.Header = header
+	}
+	return chunk, nil
+}
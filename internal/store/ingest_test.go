@@ -0,0 +1,127 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"mini-rag-go/internal/models"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// poolEmbedder 向量的第一维为文本末尾的编号，含 "bad" 的文本生成失败；
+// 记录批大小和同时进行的请求数
+type poolEmbedder struct {
+	mu        sync.Mutex
+	batches   []int
+	active    int
+	maxActive int
+}
+
+func (e *poolEmbedder) Embed(text string) ([]float32, error) {
+	if strings.Contains(text, "bad") {
+		return nil, errors.New("生成失败")
+	}
+	n, _ := strconv.Atoi(text[strings.LastIndex(text, " ")+1:])
+	return []float32{float32(n), 1}, nil
+}
+
+func (e *poolEmbedder) EmbedBatch(texts []string) ([][]float32, error) {
+	e.mu.Lock()
+	e.batches = append(e.batches, len(texts))
+	e.active++
+	e.maxActive = max(e.maxActive, e.active)
+	e.mu.Unlock()
+	defer func() {
+		e.mu.Lock()
+		e.active--
+		e.mu.Unlock()
+	}()
+	time.Sleep(time.Millisecond)
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		v, err := e.Embed(text)
+		if err != nil {
+			return nil, err
+		}
+		vectors[i] = v
+	}
+	return vectors, nil
+}
+
+func (e *poolEmbedder) Dimension() int { return 2 }
+
+// numberedChunks 生成内容为 "chunk 0"、"chunk 1"... 的文档块，bad 中的编号内容带上 "bad"
+func numberedChunks(n int, bad ...int) []models.DocumentChunk {
+	contents := make([]string, n)
+	for i := range contents {
+		contents[i] = fmt.Sprintf("chunk %d", i)
+	}
+	for _, i := range bad {
+		contents[i] = fmt.Sprintf("bad chunk %d", i)
+	}
+	return testChunks(contents...)
+}
+
+func TestAddChunks(t *testing.T) {
+	tests := []struct {
+		name        string
+		chunks      int
+		bad         []int
+		opts        IngestOptions
+		wantAdded   int
+		wantBatches int
+	}{
+		{name: "单协程", chunks: 10, opts: IngestOptions{Workers: 1, BatchSize: 4}, wantAdded: 10, wantBatches: 3},
+		{name: "多协程", chunks: 40, opts: IngestOptions{Workers: 4, BatchSize: 3}, wantAdded: 40, wantBatches: 14},
+		{name: "默认参数", chunks: 40, wantAdded: 40, wantBatches: 2},
+		{name: "失败的块被跳过", chunks: 12, bad: []int{1, 7}, opts: IngestOptions{Workers: 3, BatchSize: 4}, wantAdded: 10, wantBatches: 3},
+		{name: "没有块", chunks: 0, opts: IngestOptions{Workers: 2}, wantAdded: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			embedder := &poolEmbedder{}
+			vs := NewVectorStore(embedder)
+			var progress []int
+			var progressMu sync.Mutex
+			tt.opts.Progress = func(done, total int) {
+				progressMu.Lock()
+				defer progressMu.Unlock()
+				if total != tt.chunks {
+					t.Errorf("progress total = %d, want %d", total, tt.chunks)
+				}
+				progress = append(progress, done)
+			}
+			added, err := vs.AddChunks(numberedChunks(tt.chunks, tt.bad...), tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if added != tt.wantAdded || vs.DocumentCount() != tt.wantAdded {
+				t.Fatalf("AddChunks() = %d, DocumentCount() = %d, want %d", added, vs.DocumentCount(), tt.wantAdded)
+			}
+			if len(embedder.batches) != tt.wantBatches {
+				t.Errorf("%d batches, want %d", len(embedder.batches), tt.wantBatches)
+			}
+			if tt.opts.Workers > 0 && embedder.maxActive > tt.opts.Workers {
+				t.Errorf("%d concurrent requests, want at most %d", embedder.maxActive, tt.opts.Workers)
+			}
+			//写入顺序与输入一致，每个向量对应自己的块
+			last := -1
+			for i, chunk := range vs.documents {
+				n, _ := strconv.Atoi(chunk.Content[strings.LastIndex(chunk.Content, " ")+1:])
+				if n <= last {
+					t.Errorf("chunk %d (%q) is out of order", i, chunk.Content)
+				}
+				last = n
+				if got := int(vs.vectors[i][0]); got != n {
+					t.Errorf("chunk %q has the vector of chunk %d", chunk.Content, got)
+				}
+			}
+			if tt.chunks > 0 && (len(progress) == 0 || progress[len(progress)-1] != tt.chunks) {
+				t.Errorf("progress = %v, want it to end at %d", progress, tt.chunks)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package store
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"mini-rag-go/internal/models"
 	"mini-rag-go/internal/utils"
@@ -9,40 +10,129 @@ import (
 	"os"
 	"sort"
 	"sync"
+	"text/template"
 )
 
 // VectorStore 向量存储
 type VectorStore struct {
-	documents []models.Document
-	vectors   [][]float32
-	embedder  vector.Embedder
-	mu        sync.RWMutex
+	documents       []models.DocumentChunk
+	parents         map[string]models.DocumentChunk // 父子分块中的父块，不参与向量检索
+	vectors         [][]float32
+	questionVectors [][]float32 // 问答对中问题文本的向量，没有问题的块为 nil
+	questionWeight  float64
+	headerTemplate  *template.Template // 设置后生成嵌入前为每个块加上上下文标题
+	headerText      string             // 块标题模板原文，未设置时为空
+	mismatchPolicy  string             // 加载的存储与当前嵌入器不一致时的处理方式
+	ingest          IngestOptions      // 重新生成嵌入时使用的参数
+	embedder        vector.Embedder
+	mu              sync.RWMutex
 }
 
 // NewVectorStore 创建向量存储
 func NewVectorStore(embedder vector.Embedder) *VectorStore {
 	return &VectorStore{
-		documents: make([]models.Document, 0),
-		vectors:   make([][]float32, 0),
-		embedder:  embedder,
+		documents:       make([]models.DocumentChunk, 0),
+		parents:         make(map[string]models.DocumentChunk),
+		vectors:         make([][]float32, 0),
+		questionVectors: make([][]float32, 0),
+		questionWeight:  DefaultQuestionWeight,
+		embedder:        embedder,
 	}
 }
 
-// AddDocument 添加文档
-func (vs *VectorStore) AddDocument(doc models.Document) error {
+// 加载的存储与当前嵌入器不一致时的处理方式
+const (
+	MismatchRefuse  = "refuse"  // 拒绝加载
+	MismatchReembed = "reembed" // 用当前嵌入器重新生成全部向量
+)
+
+// ErrEmbedderMismatch 存储中的向量不是由当前嵌入器生成的
+var ErrEmbedderMismatch = errors.New("向量存储的嵌入器与当前配置不一致")
+
+// SetMismatchPolicy 设置加载的存储与当前嵌入器不一致时的处理方式，opts 为重新生成嵌入时的参数
+func (vs *VectorStore) SetMismatchPolicy(policy string, opts IngestOptions) {
+	vs.mu.Lock()
+	defer vs.mu.Unlock()
+	vs.mismatchPolicy = policy
+	vs.ingest = opts
+}
+
+// DefaultQuestionWeight 问题文本相似度在问答对得分中的默认权重
+const DefaultQuestionWeight = 0.6
+
+// SetQuestionWeight 设置问题文本相似度的权重（0~1），问答对的得分为
+// weight*问题相似度 + (1-weight)*全文相似度
+func (vs *VectorStore) SetQuestionWeight(weight float64) {
 	vs.mu.Lock()
 	defer vs.mu.Unlock()
+	if weight < 0 {
+		weight = 0
+	} else if weight > 1 {
+		weight = 1
+	}
+	vs.questionWeight = weight
+}
 
-	//生成嵌入
-	vector, err := vs.embedder.Embed(doc.Content)
+// AddDocument 添加文档
+func (vs *VectorStore) AddDocument(doc models.Document) error {
+	return vs.AddChunk(models.DocumentChunk{
+		Document: doc,
+		EndPos:   len([]rune(doc.Content)),
+		EndByte:  len(doc.Content),
+	})
+}
+
+// AddChunk 添加文档块，保留其在所属文档内容中的位置
+func (vs *VectorStore) AddChunk(chunk models.DocumentChunk) error {
+	chunk, err := vs.prepareChunk(chunk)
+	if err != nil {
+		return err
+	}
+	//生成嵌入时不持有锁，原始内容保持不变，用于显示
+	vector, err := vs.embedder.Embed(chunk.EmbeddingText())
 	if err != nil {
 		return fmt.Errorf("生成嵌入失败: %v", err)
 	}
-	vs.documents = append(vs.documents, doc)
+	//问答对的问题单独生成向量，检索时用户问题主要与之匹配
+	var questionVector []float32
+	if question := chunk.Metadata["question"]; question != "" {
+		questionVector, err = vs.embedder.Embed(question)
+		if err != nil {
+			return fmt.Errorf("生成问题嵌入失败: %v", err)
+		}
+	}
+	vs.mu.Lock()
+	defer vs.mu.Unlock()
+	vs.documents = append(vs.documents, chunk)
 	vs.vectors = append(vs.vectors, vector)
+	vs.questionVectors = append(vs.questionVectors, questionVector)
 	return nil
 }
 
+// AddParents 保存父块，子块通过 ParentID 引用
+func (vs *VectorStore) AddParents(parents []models.DocumentChunk) {
+	vs.mu.Lock()
+	defer vs.mu.Unlock()
+	for _, parent := range parents {
+		vs.parents[parent.ID] = parent
+	}
+}
+
+// Parent 返回指定ID的父块
+func (vs *VectorStore) Parent(id string) (models.DocumentChunk, bool) {
+	vs.mu.RLock()
+	defer vs.mu.RUnlock()
+	parent, ok := vs.parents[id]
+	return parent, ok
+}
+
+// HasParents 判断存储中是否有父块
+func (vs *VectorStore) HasParents() bool {
+	vs.mu.RLock()
+	defer vs.mu.RUnlock()
+	return len(vs.parents) > 0
+}
+
 // AddDocuments 批量添加文档
 func (vs *VectorStore) AddDocuments(docs []models.Document) error {
 	for _, doc := range docs {
@@ -53,8 +143,55 @@ func (vs *VectorStore) AddDocuments(docs []models.Document) error {
 	return nil
 }
 
+// RemoveDocument 删除某个源文档生成的所有文档块（包括父块），返回删除的子块数量
+func (vs *VectorStore) RemoveDocument(docID string) int {
+	vs.mu.Lock()
+	defer vs.mu.Unlock()
+
+	for id, parent := range vs.parents {
+		if parent.Metadata["doc_id"] == docID {
+			delete(vs.parents, id)
+		}
+	}
+
+	kept := 0
+	for i, doc := range vs.documents {
+		if doc.Metadata["doc_id"] == docID {
+			continue
+		}
+		vs.documents[kept] = vs.documents[i]
+		vs.vectors[kept] = vs.vectors[i]
+		vs.questionVectors[kept] = vs.questionVectors[i]
+		kept++
+	}
+	removed := len(vs.documents) - kept
+	vs.documents = vs.documents[:kept]
+	vs.vectors = vs.vectors[:kept]
+	vs.questionVectors = vs.questionVectors[:kept]
+	return removed
+}
+
+// SourceHashes 返回已索引源文档的内容哈希，键为源文档ID
+func (vs *VectorStore) SourceHashes() map[string]string {
+	vs.mu.RLock()
+	defer vs.mu.RUnlock()
+
+	hashes := make(map[string]string)
+	for _, doc := range vs.documents {
+		if id := doc.Metadata["doc_id"]; id != "" {
+			hashes[id] = doc.Metadata["content_hash"]
+		}
+	}
+	return hashes
+}
+
 // Search 搜索相似文档
 func (vs *VectorStore) Search(query string, topK int) ([]models.SearchResult, error) {
+	return vs.SearchWithFilter(query, topK, nil)
+}
+
+// SearchWithFilter 搜索相似文档，只返回元数据与 filter 中所有键值都相等的文档
+func (vs *VectorStore) SearchWithFilter(query string, topK int, filter map[string]string) ([]models.SearchResult, error) {
 	vs.mu.RLock()
 	defer vs.mu.RUnlock()
 
@@ -67,13 +204,20 @@ func (vs *VectorStore) Search(query string, topK int) ([]models.SearchResult, er
 		return nil, fmt.Errorf("生成查询向量失败：%v", err)
 	}
 	//计算相似度
-	results := make([]models.SearchResult, len(vs.documents))
+	results := make([]models.SearchResult, 0, len(vs.documents))
 	for i, vector := range vs.vectors {
+		if !matchMetadata(vs.documents[i].Metadata, filter) {
+			continue
+		}
 		score := utils.CosineSimilarity(queryVector, vector)
-		results[i] = models.SearchResult{
-			Document: vs.documents[i],
-			Score:    score,
+		if questionVector := vs.questionVectors[i]; questionVector != nil {
+			score = vs.questionWeight*utils.CosineSimilarity(queryVector, questionVector) +
+				(1-vs.questionWeight)*score
 		}
+		results = append(results, models.SearchResult{
+			DocumentChunk: vs.documents[i],
+			Score:         score,
+		})
 	}
 	//排序
 	sort.Slice(results, func(i, j int) bool {
@@ -86,16 +230,42 @@ func (vs *VectorStore) Search(query string, topK int) ([]models.SearchResult, er
 	return results[:topK], nil
 }
 
+// matchMetadata 判断元数据是否满足过滤条件
+func matchMetadata(metadata, filter map[string]string) bool {
+	for key, value := range filter {
+		if metadata[key] != value {
+			return false
+		}
+	}
+	return true
+}
+
 // Save 保存到文件
 func (vs *VectorStore) Save(filename string) error {
 	vs.mu.RLock()
 	defer vs.mu.RUnlock()
+	parents := make([]models.DocumentChunk, 0, len(vs.parents))
+	for _, parent := range vs.parents {
+		parents = append(parents, parent)
+	}
+	sort.Slice(parents, func(i, j int) bool {
+		return parents[i].ID < parents[j].ID
+	})
+	fingerprint := vector.FingerprintOf(vs.embedder)
 	data := struct {
-		Documents []models.Document `json:"documents"`
-		Vectors   [][]float32       `json:"vectors"`
+		Embedder        *vector.Fingerprint    `json:"embedder"`
+		HeaderTemplate  *string                `json:"header_template"`
+		Documents       []models.DocumentChunk `json:"documents"`
+		Vectors         [][]float32            `json:"vectors"`
+		QuestionVectors [][]float32            `json:"question_vectors,omitempty"`
+		Parents         []models.DocumentChunk `json:"parents,omitempty"`
 	}{
-		Documents: vs.documents,
-		Vectors:   vs.vectors,
+		Embedder:        &fingerprint,
+		HeaderTemplate:  &vs.headerText,
+		Documents:       vs.documents,
+		Vectors:         vs.vectors,
+		QuestionVectors: vs.questionVectors,
+		Parents:         parents,
 	}
 	jsonData, err := json.MarshalIndent(data, "", " ")
 	if err != nil {
@@ -107,25 +277,118 @@ func (vs *VectorStore) Save(filename string) error {
 	return nil
 }
 
-// Load 从文件加载
+// Load 从文件加载。文件中记录的嵌入器指纹或块标题模板与当前配置不一致时，
+// 按 SetMismatchPolicy 的设置拒绝加载，或者用当前配置重新生成全部向量并写回文件
 func (vs *VectorStore) Load(filename string) error {
+	stored, storedHeader, err := vs.load(filename)
+	if err != nil {
+		return err
+	}
+	current := vector.FingerprintOf(vs.embedder)
+	if stored == nil && (vs.vectorDimension() == 0 || vs.vectorDimension() == current.Dimension) {
+		//旧版本存储没有记录嵌入器，只能比较向量维度
+		stored = &current
+	} else if stored == nil {
+		stored = &vector.Fingerprint{Name: "unknown", Dimension: vs.vectorDimension()}
+	}
+	vs.mu.RLock()
+	currentHeader := vs.headerText
+	vs.mu.RUnlock()
+	//旧版本存储没有记录块标题模板，不做比较
+	headerChanged := storedHeader != nil && *storedHeader != currentHeader
+	if *stored == current && !headerChanged {
+		return nil
+	}
+	var reason string
+	if *stored != current {
+		if current.Dimension == 0 {
+			vs.Clear()
+			return fmt.Errorf("无法获取当前嵌入器的向量维度，请检查嵌入服务")
+		}
+		reason = fmt.Sprintf("嵌入器存储为 %s，当前为 %s", stored, current)
+	} else {
+		reason = fmt.Sprintf("块标题模板存储为 %q，当前为 %q", *storedHeader, currentHeader)
+	}
+	if vs.mismatchPolicy != MismatchReembed {
+		vs.Clear()
+		return fmt.Errorf("%w：%s；删除存储文件重新构建，或将不一致处理方式设为 %s 自动重新生成嵌入",
+			ErrEmbedderMismatch, reason, MismatchReembed)
+	}
+
+	vs.mu.Lock()
+	chunks := vs.documents
+	vs.documents = make([]models.DocumentChunk, 0, len(chunks))
+	vs.vectors = make([][]float32, 0, len(chunks))
+	vs.questionVectors = make([][]float32, 0, len(chunks))
+	ingest := vs.ingest
+	vs.mu.Unlock()
+	//块标题按当前模板重新生成
+	for i := range chunks {
+		chunks[i].Header = ""
+	}
+	fmt.Printf("嵌入配置已变化：%s，重新生成 %d 个文档块的嵌入...\n", reason, len(chunks))
+	added, err := vs.AddChunks(chunks, ingest)
+	if err != nil {
+		return fmt.Errorf("重新生成嵌入失败：%v", err)
+	}
+	if added < len(chunks) {
+		return fmt.Errorf("重新生成嵌入失败：%d 个文档块中只成功 %d 个", len(chunks), added)
+	}
+	return vs.Save(filename)
+}
+
+// load 读取存储文件，返回文件中记录的嵌入器指纹和块标题模板，旧版本文件没有时返回 nil
+func (vs *VectorStore) load(filename string) (*vector.Fingerprint, *string, error) {
 	vs.mu.Lock()
 	defer vs.mu.Unlock()
 
 	data, err := os.ReadFile(filename)
 	if err != nil {
-		return fmt.Errorf("读取文件失败：%v", err)
+		return nil, nil, fmt.Errorf("读取文件失败：%v", err)
 	}
 	var storeData struct {
-		Documents []models.Document `json:"documents"`
-		Vectors   [][]float32       `json:"vectors"`
+		Embedder        *vector.Fingerprint    `json:"embedder"`
+		HeaderTemplate  *string                `json:"header_template"`
+		Documents       []models.DocumentChunk `json:"documents"`
+		Vectors         [][]float32            `json:"vectors"`
+		QuestionVectors [][]float32            `json:"question_vectors"`
+		Parents         []models.DocumentChunk `json:"parents"`
 	}
 	if err := json.Unmarshal(data, &storeData); err != nil {
-		return fmt.Errorf("反序列化失败：%v", err)
+		return nil, nil, fmt.Errorf("反序列化失败：%v", err)
 	}
 	vs.documents = storeData.Documents
 	vs.vectors = storeData.Vectors
-	return nil
+	//旧版本存储没有问题向量
+	vs.questionVectors = storeData.QuestionVectors
+	if len(vs.questionVectors) != len(vs.documents) {
+		vs.questionVectors = make([][]float32, len(vs.documents))
+	}
+	vs.parents = make(map[string]models.DocumentChunk, len(storeData.Parents))
+	for _, parent := range storeData.Parents {
+		vs.parents[parent.ID] = parent
+	}
+	return storeData.Embedder, storeData.HeaderTemplate, nil
+}
+
+// vectorDimension 返回已存储向量的维度，没有向量时返回 0
+func (vs *VectorStore) vectorDimension() int {
+	vs.mu.RLock()
+	defer vs.mu.RUnlock()
+	if len(vs.vectors) == 0 {
+		return 0
+	}
+	return len(vs.vectors[0])
+}
+
+// Clear 清空所有文档
+func (vs *VectorStore) Clear() {
+	vs.mu.Lock()
+	defer vs.mu.Unlock()
+	vs.documents = make([]models.DocumentChunk, 0)
+	vs.parents = make(map[string]models.DocumentChunk)
+	vs.vectors = make([][]float32, 0)
+	vs.questionVectors = make([][]float32, 0)
 }
 
 // DocumentCount 返回文档数量
@@ -0,0 +1,208 @@
+package store
+
+import (
+	"errors"
+	"math"
+	"mini-rag-go/internal/models"
+	"mini-rag-go/internal/vector"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// testChunks 创建测试用的文档块
+func testChunks(contents ...string) []models.DocumentChunk {
+	var chunks []models.DocumentChunk
+	for i, content := range contents {
+		chunks = append(chunks, models.DocumentChunk{
+			Document: models.Document{
+				ID:       "doc_" + string(rune('a'+i)),
+				Content:  content,
+				Filename: "policy.txt",
+				Metadata: map[string]string{"doc_id": "doc"},
+			},
+			ChunkIndex: i,
+		})
+	}
+	return chunks
+}
+
+// savedStore 用指定的嵌入器和块标题模板生成存储文件
+func savedStore(t *testing.T, embedder vector.Embedder, header string) string {
+	t.Helper()
+	vs := NewVectorStore(embedder)
+	if header != "" {
+		if err := vs.SetHeaderTemplate(header); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := vs.AddChunks(testChunks("退款需在七天内申请。", "运费由买家承担。"), IngestOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "store.json")
+	if err := vs.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadMismatch(t *testing.T) {
+	tests := []struct {
+		name          string
+		savedDim      int
+		savedHeader   string
+		currentDim    int
+		currentHeader string
+		policy        string
+		wantErr       error
+		wantDim       int
+		wantHeader    string
+	}{
+		{name: "配置相同", savedDim: 16, currentDim: 16, policy: MismatchRefuse, wantDim: 16},
+		{name: "模板相同", savedDim: 16, savedHeader: "文档：{{.Filename}}", currentDim: 16, currentHeader: "文档：{{.Filename}}",
+			policy: MismatchRefuse, wantDim: 16, wantHeader: "文档：policy.txt"},
+		{name: "嵌入器变化时拒绝", savedDim: 16, currentDim: 32, policy: MismatchRefuse, wantErr: ErrEmbedderMismatch},
+		{name: "嵌入器变化时重新生成", savedDim: 16, currentDim: 32, policy: MismatchReembed, wantDim: 32},
+		{name: "模板变化时拒绝", savedDim: 16, savedHeader: "文档：{{.Filename}}", currentDim: 16, currentHeader: "来源：{{.Filename}}",
+			policy: MismatchRefuse, wantErr: ErrEmbedderMismatch},
+		{name: "模板变化时重新生成", savedDim: 16, savedHeader: "文档：{{.Filename}}", currentDim: 16, currentHeader: "来源：{{.Filename}}",
+			policy: MismatchReembed, wantDim: 16, wantHeader: "来源：policy.txt"},
+		{name: "关闭块标题时重新生成", savedDim: 16, savedHeader: "文档：{{.Filename}}", currentDim: 16,
+			policy: MismatchReembed, wantDim: 16},
+		{name: "开启块标题时拒绝", savedDim: 16, currentDim: 16, currentHeader: "文档：{{.Filename}}",
+			policy: MismatchRefuse, wantErr: ErrEmbedderMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := savedStore(t, vector.NewSimpleEmbedder(tt.savedDim), tt.savedHeader)
+			vs := NewVectorStore(vector.NewSimpleEmbedder(tt.currentDim))
+			if tt.currentHeader != "" {
+				if err := vs.SetHeaderTemplate(tt.currentHeader); err != nil {
+					t.Fatal(err)
+				}
+			}
+			vs.SetMismatchPolicy(tt.policy, IngestOptions{Workers: 2, BatchSize: 1})
+			err := vs.Load(path)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Load() error = %v, want %v", err, tt.wantErr)
+				}
+				if vs.DocumentCount() != 0 {
+					t.Errorf("refused store kept %d documents", vs.DocumentCount())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if vs.DocumentCount() != 2 {
+				t.Fatalf("DocumentCount() = %d, want 2", vs.DocumentCount())
+			}
+			if got := vs.vectorDimension(); got != tt.wantDim {
+				t.Errorf("vector dimension = %d, want %d", got, tt.wantDim)
+			}
+			for i, chunk := range vs.documents {
+				if chunk.Header != tt.wantHeader {
+					t.Errorf("chunk %d header = %q, want %q", i, chunk.Header, tt.wantHeader)
+				}
+			}
+			//重新生成后写回文件，再次加载不再不一致
+			reloaded := NewVectorStore(vector.NewSimpleEmbedder(tt.currentDim))
+			if tt.currentHeader != "" {
+				if err := reloaded.SetHeaderTemplate(tt.currentHeader); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := reloaded.Load(path); err != nil {
+				t.Errorf("second Load() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestLoadLegacyStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store.json")
+	legacy := `{"documents":[{"id":"a","content":"退款","filename":"a.txt","chunk_index":0}],"vectors":[[1,0,0,0]]}`
+	if err := os.WriteFile(path, []byte(legacy), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		dim     int
+		header  string
+		wantErr bool
+	}{
+		{name: "维度相同", dim: 4},
+		{name: "维度相同且设置了模板", dim: 4, header: "文档：{{.Filename}}"},
+		{name: "维度不同", dim: 8, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vs := NewVectorStore(vector.NewSimpleEmbedder(tt.dim))
+			if tt.header != "" {
+				if err := vs.SetHeaderTemplate(tt.header); err != nil {
+					t.Fatal(err)
+				}
+			}
+			err := vs.Load(path)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Load() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// fixedEmbedder 按文本返回预设向量的嵌入器，未预设的文本返回零向量
+type fixedEmbedder map[string][]float32
+
+func (e fixedEmbedder) Embed(text string) ([]float32, error) {
+	if v, ok := e[text]; ok {
+		return v, nil
+	}
+	return make([]float32, 2), nil
+}
+
+func (e fixedEmbedder) Dimension() int { return 2 }
+
+func TestSearchQuestionWeight(t *testing.T) {
+	embedder := fixedEmbedder{
+		"query": {1, 0},
+		"怎么退款？": {1, 0},
+		"Q: 怎么退款？\nA: 七天内申请。": {0, 1},
+		"退款说明": {0.5, 0.866},
+	}
+	faq := testChunks("Q: 怎么退款？\nA: 七天内申请。")[0]
+	faq.Metadata["question"] = "怎么退款？"
+	plain := testChunks("", "退款说明")[1]
+	tests := []struct {
+		weight    float64
+		wantFirst string
+		wantScore float64
+	}{
+		{weight: 0.6, wantFirst: faq.ID, wantScore: 0.6},
+		{weight: 0, wantFirst: plain.ID, wantScore: 0},
+		{weight: 1, wantFirst: faq.ID, wantScore: 1},
+		{weight: 2, wantFirst: faq.ID, wantScore: 1},
+	}
+	for _, tt := range tests {
+		vs := NewVectorStore(embedder)
+		vs.SetQuestionWeight(tt.weight)
+		for _, chunk := range []models.DocumentChunk{faq, plain} {
+			if err := vs.AddChunk(chunk); err != nil {
+				t.Fatal(err)
+			}
+		}
+		results, err := vs.Search("query", 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if results[0].ID != tt.wantFirst {
+			t.Errorf("weight %v: first result = %s, want %s", tt.weight, results[0].ID, tt.wantFirst)
+		}
+		for _, result := range results {
+			if result.ID == faq.ID && math.Abs(result.Score-tt.wantScore) > 1e-6 {
+				t.Errorf("weight %v: FAQ score = %v, want %v", tt.weight, result.Score, tt.wantScore)
+			}
+		}
+	}
+}
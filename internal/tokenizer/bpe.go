@@ -0,0 +1,254 @@
+package tokenizer
+
+import (
+	"bufio"
+	"container/heap"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// maxCacheEntries 片段编码缓存的最大条目数
+const maxCacheEntries = 100000
+
+// BPETokenizer 字节级BPE分词器，词表为 tiktoken 格式（每行 "base64编码的token 排名"），
+// 与 OpenAI、Qwen 等模型发布的 .tiktoken 词表文件兼容
+type BPETokenizer struct {
+	ranks map[string]int
+	mu    sync.Mutex
+	cache map[string][]int
+}
+
+// LoadBPE 从本地词表文件加载BPE分词器
+func LoadBPE(path string) (*BPETokenizer, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("打开词表失败：%v", err)
+	}
+	defer file.Close()
+
+	ranks := make(map[string]int)
+	scanner := bufio.NewScanner(file)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("词表第%d行格式错误", lineNum)
+		}
+		token, err := base64.StdEncoding.DecodeString(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("词表第%d行token解码失败：%v", lineNum, err)
+		}
+		rank, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("词表第%d行排名无效：%v", lineNum, err)
+		}
+		ranks[string(token)] = rank
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取词表失败：%v", err)
+	}
+	if len(ranks) == 0 {
+		return nil, fmt.Errorf("词表为空：%s", path)
+	}
+	return NewBPE(ranks), nil
+}
+
+// NewBPE 使用token到排名的映射创建BPE分词器
+func NewBPE(ranks map[string]int) *BPETokenizer {
+	return &BPETokenizer{
+		ranks: ranks,
+		cache: make(map[string][]int),
+	}
+}
+
+// Count 返回token数
+func (t *BPETokenizer) Count(text string) int {
+	return len(t.Encode(text))
+}
+
+// Encode 将文本编码为token ID序列
+func (t *BPETokenizer) Encode(text string) []int {
+	var ids []int
+	for _, piece := range pretokenize(text) {
+		ids = append(ids, t.encodePiece(piece)...)
+	}
+	return ids
+}
+
+// encodePiece 对单个预分词片段做BPE合并，较短的片段缓存结果
+func (t *BPETokenizer) encodePiece(piece string) []int {
+	if rank, ok := t.ranks[piece]; ok {
+		return []int{rank}
+	}
+	cacheable := len(piece) <= maxCachedPieceLen
+	if cacheable {
+		t.mu.Lock()
+		cached, ok := t.cache[piece]
+		t.mu.Unlock()
+		if ok {
+			return cached
+		}
+	}
+
+	ids := make([]int, 0, len(piece)/2+1)
+	for _, part := range t.merge(piece) {
+		if rank, ok := t.ranks[part]; ok {
+			ids = append(ids, rank)
+		} else {
+			//词表缺少的字节按每字节一个token计
+			for range len(part) {
+				ids = append(ids, -1)
+			}
+		}
+	}
+
+	if cacheable {
+		t.mu.Lock()
+		if len(t.cache) >= maxCacheEntries {
+			t.cache = make(map[string][]int)
+		}
+		t.cache[piece] = ids
+		t.mu.Unlock()
+	}
+	return ids
+}
+
+// maxCachedPieceLen 缓存的片段最大字节数：没有空格的中文等长片段在分块时会被截成各种子串，缓存命中率很低
+const maxCachedPieceLen = 64
+
+// merge 从单字节开始，反复合并排名最小的相邻片段（排名相同时取最左边的），直到没有可合并的片段。
+// 片段用双向链表连接，候选的相邻对放在按排名排序的堆中，复杂度为 O(n log n)
+func (t *BPETokenizer) merge(piece string) []string {
+	n := len(piece)
+	end := make([]int, n) //每个片段从其起始字节开始，end 为结束字节
+	prev := make([]int, n)
+	next := make([]int, n)
+	for i := range n {
+		end[i], prev[i], next[i] = i+1, i-1, i+1
+	}
+	var candidates pairHeap
+	push := func(left int) {
+		if left < 0 || next[left] >= n {
+			return
+		}
+		right := next[left]
+		if rank, ok := t.ranks[piece[left:end[right]]]; ok {
+			heap.Push(&candidates, pair{rank: rank, left: left, right: right, end: end[right]})
+		}
+	}
+	for i := 0; i+1 < n; i++ {
+		push(i)
+	}
+	for candidates.Len() > 0 {
+		p := heap.Pop(&candidates).(pair)
+		//两个片段之一在入堆之后已经参与过合并，这个候选失效
+		if next[p.left] != p.right || end[p.right] != p.end || end[p.left] != p.right {
+			continue
+		}
+		end[p.left] = p.end
+		next[p.left] = next[p.right]
+		if next[p.right] < n {
+			prev[next[p.right]] = p.left
+		}
+		end[p.right] = -1
+		push(prev[p.left])
+		push(p.left)
+	}
+	var parts []string
+	for i := 0; i < n; i = next[i] {
+		parts = append(parts, piece[i:end[i]])
+	}
+	return parts
+}
+
+// pair 可合并的相邻片段，left、right 为两个片段的起始字节，end 为 right 的结束字节
+type pair struct {
+	rank, left, right, end int
+}
+
+// pairHeap 按排名、位置排序的最小堆，实现 heap.Interface
+type pairHeap []pair
+
+func (h pairHeap) Len() int { return len(h) }
+func (h pairHeap) Less(i, j int) bool {
+	if h[i].rank != h[j].rank {
+		return h[i].rank < h[j].rank
+	}
+	return h[i].left < h[j].left
+}
+func (h pairHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+func (h *pairHeap) Push(x any)   { *h = append(*h, x.(pair)) }
+func (h *pairHeap) Pop() any {
+	old := *h
+	p := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return p
+}
+
+// pretokenize 近似 tiktoken 的预分词规则：单词可带一个前导空格，
+// 数字最多3位一组，标点连续成组，空白单独成组
+func pretokenize(text string) []string {
+	var pieces []string
+	start := 0
+	for start < len(text) {
+		end := start
+		r, size := utf8.DecodeRuneInString(text[end:])
+		//单个前导空格与后面的单词或标点合并
+		if r == ' ' && start+size < len(text) {
+			next, _ := utf8.DecodeRuneInString(text[start+size:])
+			if !unicode.IsSpace(next) {
+				end += size
+				r, size = next, utf8.RuneLen(next)
+			}
+		}
+		class := runeClass(r)
+		count := 0
+		for end < len(text) {
+			r, size = utf8.DecodeRuneInString(text[end:])
+			if runeClass(r) != class || class == classDigit && count == 3 {
+				break
+			}
+			end += size
+			count++
+		}
+		if end == start {
+			_, size = utf8.DecodeRuneInString(text[start:])
+			end = start + size
+		}
+		pieces = append(pieces, text[start:end])
+		start = end
+	}
+	return pieces
+}
+
+// 预分词的字符类别
+const (
+	classLetter = iota
+	classDigit
+	classSpace
+	classOther
+)
+
+// runeClass 返回字符类别
+func runeClass(r rune) int {
+	switch {
+	case unicode.IsLetter(r) || unicode.IsMark(r):
+		return classLetter
+	case unicode.IsDigit(r):
+		return classDigit
+	case unicode.IsSpace(r):
+		return classSpace
+	}
+	return classOther
+}
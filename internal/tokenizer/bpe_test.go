@@ -0,0 +1,157 @@
+package tokenizer
+
+import (
+	"math/rand"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// 测试词表 testdata/small.tiktoken：
+// a=0 b=1 c=2 " "=3 d=4，"退""款"的各个字节为5~9，ab=10 bc=11 abc=12 " a"=13 " ab"=14 dd=15，
+// 退=17 款=19 退款=22，其余为合并所需的中间token
+func loadSmall(t *testing.T) *BPETokenizer {
+	t.Helper()
+	bpe, err := LoadBPE(filepath.Join("testdata", "small.tiktoken"))
+	if err != nil {
+		t.Fatalf("LoadBPE() error = %v", err)
+	}
+	return bpe
+}
+
+func TestLoadBPE(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{name: "正常", content: "YQ== 0\nYg== 1\n\nYWI= 2\n"},
+		{name: "字段数错误", content: "YQ== 0 extra\n", wantErr: "第1行格式错误"},
+		{name: "base64错误", content: "YQ== 0\n!!! 1\n", wantErr: "第2行token解码失败"},
+		{name: "排名错误", content: "YQ== x\n", wantErr: "第1行排名无效"},
+		{name: "空词表", content: "\n\n", wantErr: "词表为空"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "vocab.tiktoken")
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			bpe, err := LoadBPE(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("LoadBPE() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadBPE() error = %v", err)
+			}
+			if got := bpe.Encode("ab"); !slices.Equal(got, []int{2}) {
+				t.Errorf("Encode(\"ab\") = %v, want [2]", got)
+			}
+		})
+	}
+	if _, err := LoadBPE(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Errorf("LoadBPE() of a missing file succeeded")
+	}
+}
+
+func TestBPEEncode(t *testing.T) {
+	bpe := loadSmall(t)
+	tests := []struct {
+		text string
+		want []int
+	}{
+		{text: "", want: nil},
+		{text: "abc", want: []int{12}},
+		{text: "abcd", want: []int{12, 4}},
+		{text: " abc", want: []int{3, 12}},
+		{text: "dddd", want: []int{15, 15}},
+		{text: "ddd", want: []int{15, 4}},
+		{text: "退款", want: []int{22}},
+		{text: "退款abc", want: []int{22, 12}},
+		{text: "退退", want: []int{17, 17}},
+		{text: "x", want: []int{-1}},
+		{text: "ab 12", want: []int{10, 3, -1, -1}},
+	}
+	for _, tt := range tests {
+		if got := bpe.Encode(tt.text); !slices.Equal(got, tt.want) {
+			t.Errorf("Encode(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+		if got := bpe.Count(tt.text); got != len(tt.want) {
+			t.Errorf("Count(%q) = %d, want %d", tt.text, got, len(tt.want))
+		}
+	}
+}
+
+// naiveMerge 逐轮扫描所有相邻对的参考实现
+func naiveMerge(ranks map[string]int, piece string) []string {
+	parts := make([]string, len(piece))
+	for i := range len(piece) {
+		parts[i] = piece[i : i+1]
+	}
+	for len(parts) > 1 {
+		best, bestRank := -1, 0
+		for i := 0; i+1 < len(parts); i++ {
+			if rank, ok := ranks[parts[i]+parts[i+1]]; ok && (best < 0 || rank < bestRank) {
+				best, bestRank = i, rank
+			}
+		}
+		if best < 0 {
+			break
+		}
+		parts[best] += parts[best+1]
+		parts = append(parts[:best+1], parts[best+2:]...)
+	}
+	return parts
+}
+
+func TestBPEMergeMatchesNaive(t *testing.T) {
+	bpe := loadSmall(t)
+	alphabet := []string{"a", "b", "c", "d", " ", "退", "款"}
+	rng := rand.New(rand.NewSource(1))
+	for range 500 {
+		var sb strings.Builder
+		for range rng.Intn(30) + 1 {
+			sb.WriteString(alphabet[rng.Intn(len(alphabet))])
+		}
+		piece := sb.String()
+		if got, want := bpe.merge(piece), naiveMerge(bpe.ranks, piece); !slices.Equal(got, want) {
+			t.Fatalf("merge(%q) = %q, want %q", piece, got, want)
+		}
+	}
+}
+
+func TestBPELongPiece(t *testing.T) {
+	bpe := loadSmall(t)
+	//没有空格的长中文片段不应缓存，也不应退化为平方复杂度
+	text := strings.Repeat("退款", 20000)
+	if got := bpe.Count(text); got != 20000 {
+		t.Errorf("Count() = %d, want 20000", got)
+	}
+	if len(bpe.cache) != 0 {
+		t.Errorf("long piece was cached")
+	}
+}
+
+func TestEstimateTokenizer(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+	}{
+		{text: "", want: 0},
+		{text: "退款", want: 2},
+		{text: "refund", want: 2},
+		{text: "refund policy", want: 4},
+		{text: "退款refund。", want: 5},
+	}
+	tok := NewEstimateTokenizer()
+	for _, tt := range tests {
+		if got := tok.Count(tt.text); got != tt.want {
+			t.Errorf("Count(%q) = %d, want %d", tt.text, got, tt.want)
+		}
+	}
+}
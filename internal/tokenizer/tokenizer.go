@@ -0,0 +1,50 @@
+package tokenizer
+
+import (
+	"unicode"
+)
+
+// Tokenizer 分词器接口，用于按token计算文本长度
+type Tokenizer interface {
+	Count(text string) int
+}
+
+// EstimateTokenizer 不依赖词表的估算分词器：
+// 中日韩字符每个约1个token，其他连续字母数字约每4个字符1个token，标点各1个token
+type EstimateTokenizer struct{}
+
+// NewEstimateTokenizer 创建估算分词器
+func NewEstimateTokenizer() *EstimateTokenizer {
+	return &EstimateTokenizer{}
+}
+
+// Count 估算token数
+func (t *EstimateTokenizer) Count(text string) int {
+	count, word := 0, 0
+	flush := func() {
+		count += (word + 3) / 4
+		word = 0
+	}
+	for _, r := range text {
+		switch {
+		case isCJK(r):
+			flush()
+			count++
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			word++
+		case unicode.IsSpace(r):
+			flush()
+		default:
+			flush()
+			count++
+		}
+	}
+	flush()
+	return count
+}
+
+// isCJK 判断是否为中日韩文字
+func isCJK(r rune) bool {
+	return unicode.Is(unicode.Han, r) || unicode.Is(unicode.Hiragana, r) ||
+		unicode.Is(unicode.Katakana, r) || unicode.Is(unicode.Hangul, r)
+}
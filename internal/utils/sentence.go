@@ -0,0 +1,163 @@
+package utils
+
+import (
+	"strings"
+	"unicode"
+)
+
+// abbreviations 常见英文缩写（小写，不含末尾的点），后面的点不是句末
+var abbreviations = map[string]bool{
+	"mr": true, "mrs": true, "ms": true, "dr": true, "prof": true, "sr": true, "jr": true,
+	"st": true, "mt": true, "vs": true, "etc": true, "e.g": true, "i.e": true, "cf": true,
+	"al": true, "approx": true, "inc": true, "ltd": true, "co": true, "corp": true,
+	"no": true, "nos": true, "fig": true, "vol": true, "pp": true, "dept": true, "est": true,
+	"a.m": true, "p.m": true, "u.s": true, "u.k": true, "jan": true, "feb": true, "mar": true,
+	"apr": true, "jun": true, "jul": true, "aug": true, "sep": true, "sept": true, "oct": true,
+	"nov": true, "dec": true,
+}
+
+// sentenceEndAbbreviations 常出现在句末的缩写，后面跟大写字母开头的词时视为句末
+var sentenceEndAbbreviations = map[string]bool{
+	"etc": true, "inc": true, "ltd": true, "co": true, "corp": true,
+}
+
+// SentenceEnds 返回文本中每个句子的结束位置（按字符计，不含），句子首尾相接覆盖全文。
+// 句末包括中文的 。！？；…、英文的 .!? 和换行，句末标点后的右引号、右括号归入当前句子；
+// 小数（3.5）、网址和邮箱（example.com）、常见缩写（e.g.、Dr.）、姓名首字母（J. K.）、
+// 行首的列表序号（1.）以及后面跟小写字母的省略号都不会断句
+func SentenceEnds(runes []rune) []int {
+	var ends []int
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		if r == '\n' {
+			ends = append(ends, i+1)
+			continue
+		}
+		if !isTerminator(r) {
+			continue
+		}
+		//连续的句末标点（如 ?!、……、...）作为一个整体
+		start := i
+		for i+1 < len(runes) && isTerminator(runes[i+1]) {
+			i++
+		}
+		end := i + 1
+		for end < len(runes) && isClosingQuote(runes[end]) {
+			end++
+		}
+		if isSentenceBreak(runes, start, i+1, end) {
+			ends = append(ends, end)
+		}
+		i = end - 1
+	}
+	if len(ends) == 0 || ends[len(ends)-1] < len(runes) {
+		ends = append(ends, len(runes))
+	}
+	return ends
+}
+
+// isTerminator 判断是否为可能的句末标点
+func isTerminator(r rune) bool {
+	switch r {
+	case '。', '！', '？', '；', '…', '.', '!', '?', '｡':
+		return true
+	}
+	return false
+}
+
+// isClosingQuote 判断是否为句末标点后可能出现的右引号或右括号
+func isClosingQuote(r rune) bool {
+	switch r {
+	case '"', '\'', '”', '’', '」', '』', '）', ')', '】', '》', ']':
+		return true
+	}
+	return false
+}
+
+// isSentenceBreak 判断标点组 runes[start:stop]（其后到 end 为右引号）之后是否断句
+func isSentenceBreak(runes []rune, start, stop, end int) bool {
+	dots := 0
+	for _, r := range runes[start:stop] {
+		switch r {
+		case '.':
+			dots++
+		case '!', '?':
+		default:
+			//含有中文句末标点时总是断句
+			return true
+		}
+	}
+	var next rune
+	if end < len(runes) {
+		next = runes[end]
+	}
+	//英文标点后面紧跟字母数字或其他标点（如 3.5、example.com、e.g.,）不是句末；中文字符例外
+	if next != 0 && !unicode.IsSpace(next) && !unicode.Is(unicode.Han, next) {
+		return false
+	}
+	if dots < stop-start {
+		//含有 ! 或 ?
+		return true
+	}
+	nextWord := firstLetterAfter(runes, end)
+	if dots > 1 {
+		//省略号：后面跟小写字母时是句中停顿
+		return !unicode.IsLower(nextWord)
+	}
+	word := wordBefore(runes, start)
+	lower := strings.ToLower(word)
+	switch {
+	case word == "":
+		return true
+	case isListMarker(runes, start, word):
+		return false
+	case len([]rune(word)) == 1 && unicode.IsUpper([]rune(word)[0]):
+		//姓名首字母，如 J. K. Rowling
+		return false
+	case abbreviations[lower]:
+		return sentenceEndAbbreviations[lower] && unicode.IsUpper(nextWord)
+	}
+	//后面以小写字母开头，多半是未收录的缩写
+	return !unicode.IsLower(nextWord)
+}
+
+// wordBefore 返回位置 i 之前紧挨着的单词（可含内部的点，如 e.g）
+func wordBefore(runes []rune, i int) string {
+	start := i
+	for start > 0 {
+		r := runes[start-1]
+		if unicode.IsLetter(r) && !unicode.Is(unicode.Han, r) || unicode.IsDigit(r) || r == '.' {
+			start--
+			continue
+		}
+		break
+	}
+	return strings.Trim(string(runes[start:i]), ".")
+}
+
+// isListMarker 判断 word 加上点是否为行首的列表序号，如 "1."、"a."
+func isListMarker(runes []rune, dot int, word string) bool {
+	start := dot - len([]rune(word))
+	for j := start - 1; j >= 0 && runes[j] != '\n'; j-- {
+		if !unicode.IsSpace(runes[j]) {
+			return false
+		}
+	}
+	if strings.IndexFunc(word, func(r rune) bool { return !unicode.IsDigit(r) }) == -1 {
+		return true
+	}
+	return len([]rune(word)) == 1
+}
+
+// firstLetterAfter 返回位置 i 之后跳过空白的第一个字符，没有时返回 0
+func firstLetterAfter(runes []rune, i int) rune {
+	for ; i < len(runes); i++ {
+		if runes[i] == '\n' {
+			return 0
+		}
+		if !unicode.IsSpace(runes[i]) {
+			return runes[i]
+		}
+	}
+	return 0
+}
@@ -0,0 +1,143 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitTextBySentences(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{
+			name: "中文句末标点",
+			text: "退款需要在七天内申请。退款会原路返回！还有问题吗？",
+			want: []string{"退款需要在七天内申请。", "退款会原路返回！", "还有问题吗？"},
+		},
+		{
+			name: "小数",
+			text: "运费为3.5元。请在下单前确认。",
+			want: []string{"运费为3.5元。", "请在下单前确认。"},
+		},
+		{
+			name: "英文缩写",
+			text: "Bring documents, e.g. receipts and invoices. Then wait.",
+			want: []string{"Bring documents, e.g. receipts and invoices.", "Then wait."},
+		},
+		{
+			name: "缩写后跟逗号",
+			text: "Some items, e.g., books, cannot be returned.",
+			want: []string{"Some items, e.g., books, cannot be returned."},
+		},
+		{
+			name: "称谓缩写",
+			text: "Dr. Smith approved it. It was fast.",
+			want: []string{"Dr. Smith approved it.", "It was fast."},
+		},
+		{
+			name: "网址",
+			text: "访问 https://example.com/refund.html 了解详情。谢谢。",
+			want: []string{"访问 https://example.com/refund.html 了解详情。", "谢谢。"},
+		},
+		{
+			name: "邮箱",
+			text: "Contact support@example.com for help. We reply within a day.",
+			want: []string{"Contact support@example.com for help.", "We reply within a day."},
+		},
+		{
+			name: "列表序号",
+			text: "1. 登录账号\n2. 提交申请",
+			want: []string{"1. 登录账号", "2. 提交申请"},
+		},
+		{
+			name: "字母列表序号",
+			text: "a. open the box\nb. check the item",
+			want: []string{"a. open the box", "b. check the item"},
+		},
+		{
+			name: "中文省略号",
+			text: "我们正在处理……请稍候。",
+			want: []string{"我们正在处理……", "请稍候。"},
+		},
+		{
+			name: "英文省略号后跟小写",
+			text: "Well... maybe later. Thanks.",
+			want: []string{"Well... maybe later.", "Thanks."},
+		},
+		{
+			name: "英文省略号后跟大写",
+			text: "Wait... The order shipped.",
+			want: []string{"Wait...", "The order shipped."},
+		},
+		{
+			name: "中文分号",
+			text: "七天内可退货；十五天内可换货。",
+			want: []string{"七天内可退货；", "十五天内可换货。"},
+		},
+		{
+			name: "句末标点后的右引号",
+			text: "客服说：“请保留发票。”然后挂断了。",
+			want: []string{"客服说：“请保留发票。”", "然后挂断了。"},
+		},
+		{
+			name: "英文句末标点后的右引号",
+			text: `He said "Yes." Then he left.`,
+			want: []string{`He said "Yes."`, "Then he left."},
+		},
+		{
+			name: "连续的问号和感叹号",
+			text: "真的吗？！太好了。",
+			want: []string{"真的吗？！", "太好了。"},
+		},
+		{
+			name: "姓名首字母",
+			text: "J. K. Rowling wrote it. Done.",
+			want: []string{"J. K. Rowling wrote it.", "Done."},
+		},
+		{
+			name: "中英混排",
+			text: "Refunds take 3 days.退款需要三天。OK!",
+			want: []string{"Refunds take 3 days.", "退款需要三天。", "OK!"},
+		},
+		{
+			name: "没有句末标点",
+			text: "退款流程说明",
+			want: []string{"退款流程说明"},
+		},
+		{
+			name: "空文本",
+			text: "",
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SplitTextBySentences(tt.text)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SplitTextBySentences(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSentenceEndsCoverText(t *testing.T) {
+	tests := []string{
+		"第一句。第二句！\n\n第三句",
+		"Hello world. 你好……",
+		"  前导空白。  ",
+	}
+	for _, text := range tests {
+		runes := []rune(text)
+		ends := SentenceEnds(runes)
+		if len(ends) == 0 || ends[len(ends)-1] != len(runes) {
+			t.Errorf("SentenceEnds(%q) = %v, want last end %d", text, ends, len(runes))
+		}
+		for i := 1; i < len(ends); i++ {
+			if ends[i] <= ends[i-1] {
+				t.Errorf("SentenceEnds(%q) = %v, not increasing", text, ends)
+			}
+		}
+	}
+}
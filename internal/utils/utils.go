@@ -67,24 +67,16 @@ func NormalizeVector(vector []float32) {
 	}
 }
 
-// SplitTextBySentences 按句子分割文本
+// SplitTextBySentences 按句子分割文本，支持中英文混排，规则见 SentenceEnds
 func SplitTextBySentences(text string) []string {
+	runes := []rune(text)
 	var sentences []string
-	var current strings.Builder
-	for _, r := range text {
-		current.WriteRune(r)
-		//简单的中英文句子结束判断
-		if r == '。' || r == '！' || r == '？' ||
-			r == '.' || r == '!' || r == '?' {
-			// 检查是否是缩写
-			if current.Len() > 1 {
-				sentences = append(sentences, strings.TrimSpace(current.String()))
-				current.Reset()
-			}
+	start := 0
+	for _, end := range SentenceEnds(runes) {
+		if sentence := strings.TrimSpace(string(runes[start:end])); sentence != "" {
+			sentences = append(sentences, sentence)
 		}
-	}
-	if current.Len() > 0 {
-		sentences = append(sentences, strings.TrimSpace(current.String()))
+		start = end
 	}
 	return sentences
 }
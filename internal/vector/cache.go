@@ -0,0 +1,331 @@
+package vector
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Identifier 能提供自身标识的嵌入器，标识应包含模型名称，不同模型的向量不能混用
+type Identifier interface {
+	ID() string
+}
+
+// Identity 返回嵌入器的标识，未实现 Identifier 时使用类型名
+func Identity(e Embedder) string {
+	if id, ok := e.(Identifier); ok {
+		return id.ID()
+	}
+	return fmt.Sprintf("%T", e)
+}
+
+// CachedEmbedder 带磁盘缓存的嵌入器，包装任意 Embedder。
+// 缓存键为文本哈希 + 嵌入器指纹（含模型、维度和版本），每条缓存是目录下的一个文件；
+// 总大小超过上限时按最近使用时间淘汰旧条目
+type CachedEmbedder struct {
+	inner    Embedder
+	dir      string
+	maxBytes int64
+
+	//指纹在第一次使用时获取，嵌入服务暂时不可用时直接调用被包装的嵌入器，下次再试
+	fpMu      sync.Mutex
+	prefix    string
+	dimension int
+
+	mu      sync.Mutex
+	entries map[string]*cacheEntry
+	size    int64
+	hits    int
+	misses  int
+}
+
+// cacheEntry 缓存条目的大小和最近使用时间
+type cacheEntry struct {
+	size int64
+	used time.Time
+}
+
+// cacheLowWater 淘汰时删到大小上限的这个比例以下，避免之后每次写入都要淘汰
+const cacheLowWater = 0.9
+
+// NewCachedEmbedder 创建带磁盘缓存的嵌入器，maxBytes 为缓存目录的大小上限。
+// 缓存文件放在两位十六进制名称的子目录中，目录下的其他文件不会被读取或删除
+func NewCachedEmbedder(inner Embedder, dir string, maxBytes int64) (*CachedEmbedder, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("创建缓存目录失败：%v", err)
+	}
+	c := &CachedEmbedder{
+		inner:    inner,
+		dir:      dir,
+		maxBytes: maxBytes,
+		entries:  make(map[string]*cacheEntry),
+	}
+	//扫描已有的缓存文件
+	shards, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("读取缓存目录失败：%v", err)
+	}
+	for _, shard := range shards {
+		if !shard.IsDir() || len(shard.Name()) != 2 || !isHex(shard.Name()) {
+			continue
+		}
+		files, err := os.ReadDir(filepath.Join(dir, shard.Name()))
+		if err != nil {
+			continue
+		}
+		for _, file := range files {
+			name := file.Name()
+			if file.IsDir() {
+				continue
+			}
+			if strings.HasSuffix(name, ".tmp") {
+				//中断写入留下的临时文件
+				os.Remove(filepath.Join(dir, shard.Name(), name))
+				continue
+			}
+			if len(name) != sha256.Size*2 || !isHex(name) || name[:2] != shard.Name() {
+				continue
+			}
+			info, err := file.Info()
+			if err != nil {
+				continue
+			}
+			c.entries[name] = &cacheEntry{size: info.Size(), used: info.ModTime()}
+			c.size += info.Size()
+		}
+	}
+	return c, nil
+}
+
+// isHex 判断是否只包含小写十六进制字符
+func isHex(s string) bool {
+	for _, r := range s {
+		if !('0' <= r && r <= '9' || 'a' <= r && r <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// ID 缓存不改变向量，标识与被包装的嵌入器相同
+func (c *CachedEmbedder) ID() string {
+	return Identity(c.inner)
+}
+
+// Fingerprint 返回被包装的嵌入器的指纹
+func (c *CachedEmbedder) Fingerprint() Fingerprint {
+	return FingerprintOf(c.inner)
+}
+
+// Dimension 返回向量维度
+func (c *CachedEmbedder) Dimension() int {
+	if _, dimension, ok := c.resolve(); ok {
+		return dimension
+	}
+	return 0
+}
+
+// resolve 获取缓存键前缀和向量维度，获取不到维度时返回 false，不缓存失败结果
+func (c *CachedEmbedder) resolve() (prefix string, dimension int, ok bool) {
+	c.fpMu.Lock()
+	defer c.fpMu.Unlock()
+	if c.dimension == 0 {
+		fp := FingerprintOf(c.inner)
+		if fp.Dimension <= 0 {
+			return "", 0, false
+		}
+		c.prefix = fp.String() + "\x00"
+		c.dimension = fp.Dimension
+	}
+	return c.prefix, c.dimension, true
+}
+
+// Embed 生成嵌入向量，命中缓存时直接返回
+func (c *CachedEmbedder) Embed(text string) ([]float32, error) {
+	prefix, dimension, ok := c.resolve()
+	if !ok {
+		return c.inner.Embed(text)
+	}
+	key := cacheKey(prefix, text)
+	if vector, ok := c.get(key, dimension); ok {
+		return vector, nil
+	}
+	vector, err := c.inner.Embed(text)
+	if err != nil {
+		return nil, err
+	}
+	c.put(key, vector)
+	return vector, nil
+}
+
+// EmbedBatch 批量生成嵌入向量，只把未命中缓存的文本交给被包装的嵌入器
+func (c *CachedEmbedder) EmbedBatch(texts []string) ([][]float32, error) {
+	prefix, dimension, ok := c.resolve()
+	if !ok {
+		return BatchEmbed(c.inner, texts)
+	}
+	vectors := make([][]float32, len(texts))
+	keys := make([]string, len(texts))
+	var missing []int
+	for i, text := range texts {
+		keys[i] = cacheKey(prefix, text)
+		if vector, ok := c.get(keys[i], dimension); ok {
+			vectors[i] = vector
+		} else {
+			missing = append(missing, i)
+		}
+	}
+	if len(missing) == 0 {
+		return vectors, nil
+	}
+	missingTexts := make([]string, len(missing))
+	for j, i := range missing {
+		missingTexts[j] = texts[i]
+	}
+	embedded, err := BatchEmbed(c.inner, missingTexts)
+	if err != nil {
+		return nil, err
+	}
+	for j, i := range missing {
+		vectors[i] = embedded[j]
+		c.put(keys[i], embedded[j])
+	}
+	return vectors, nil
+}
+
+// Stats 返回缓存命中和未命中的次数
+func (c *CachedEmbedder) Stats() (hits, misses int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.hits, c.misses
+}
+
+// cacheKey 计算缓存键
+func cacheKey(prefix, text string) string {
+	sum := sha256.Sum256([]byte(prefix + text))
+	return hex.EncodeToString(sum[:])
+}
+
+// path 返回缓存文件路径，按键的前两位分子目录
+func (c *CachedEmbedder) path(key string) string {
+	return filepath.Join(c.dir, key[:2], key)
+}
+
+// get 读取缓存的向量，读文件时不持有锁
+func (c *CachedEmbedder) get(key string, dimension int) ([]float32, bool) {
+	c.mu.Lock()
+	_, ok := c.entries[key]
+	if !ok {
+		c.misses++
+	}
+	c.mu.Unlock()
+	if !ok {
+		return nil, false
+	}
+
+	data, err := os.ReadFile(c.path(key))
+	if err != nil || len(data) != dimension*4 {
+		//文件损坏或已被删除
+		c.mu.Lock()
+		c.drop(key)
+		c.misses++
+		c.mu.Unlock()
+		os.Remove(c.path(key))
+		return nil, false
+	}
+	vector := make([]float32, dimension)
+	for i := range vector {
+		vector[i] = math.Float32frombits(binary.LittleEndian.Uint32(data[i*4:]))
+	}
+	//更新使用时间，淘汰时保留最近用过的条目
+	now := time.Now()
+	_ = os.Chtimes(c.path(key), now, now)
+	c.mu.Lock()
+	if entry, ok := c.entries[key]; ok {
+		entry.used = now
+	}
+	c.hits++
+	c.mu.Unlock()
+	return vector, true
+}
+
+// put 写入缓存，写入失败时只是不缓存
+func (c *CachedEmbedder) put(key string, vector []float32) {
+	data := make([]byte, len(vector)*4)
+	for i, v := range vector {
+		binary.LittleEndian.PutUint32(data[i*4:], math.Float32bits(v))
+	}
+	path := c.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return
+	}
+	//先写临时文件再改名，避免并发读到半个文件
+	tmp, err := os.CreateTemp(filepath.Dir(path), key+".*.tmp")
+	if err != nil {
+		return
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return
+	}
+	c.mu.Lock()
+	if old, ok := c.entries[key]; ok {
+		c.size -= old.size
+	}
+	c.entries[key] = &cacheEntry{size: int64(len(data)), used: time.Now()}
+	c.size += int64(len(data))
+	evicted := c.evict()
+	c.mu.Unlock()
+	//释放锁之后再删除文件
+	for _, key := range evicted {
+		os.Remove(c.path(key))
+	}
+}
+
+// evict 缓存超过大小上限时，按最近使用时间从旧到新淘汰条目，直到低于上限的 90%；
+// 返回被淘汰的键，由调用方在释放锁之后删除文件
+func (c *CachedEmbedder) evict() []string {
+	if c.maxBytes <= 0 || c.size <= c.maxBytes {
+		return nil
+	}
+	keys := make([]string, 0, len(c.entries))
+	for key := range c.entries {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return c.entries[keys[i]].used.Before(c.entries[keys[j]].used)
+	})
+	lowWater := int64(float64(c.maxBytes) * cacheLowWater)
+	var evicted []string
+	for _, key := range keys {
+		if c.size <= lowWater {
+			break
+		}
+		c.drop(key)
+		evicted = append(evicted, key)
+	}
+	return evicted
+}
+
+// drop 从索引中删除缓存条目，不删除文件
+func (c *CachedEmbedder) drop(key string) {
+	if entry, ok := c.entries[key]; ok {
+		c.size -= entry.size
+		delete(c.entries, key)
+	}
+}
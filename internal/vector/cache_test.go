@@ -0,0 +1,174 @@
+package vector
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestCachedEmbedderKeepsUnrelatedFiles(t *testing.T) {
+	dir := t.TempDir()
+	unrelated := []string{
+		"important_notes.txt",
+		filepath.Join("sub", "report.pdf"),
+		filepath.Join("ab", "notes.txt"),
+		filepath.Join("zz", "leftover.tmp"),
+	}
+	for _, name := range unrelated {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("data"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	leftover := filepath.Join(dir, "ab", "ab00.123.tmp")
+	if err := os.WriteFile(leftover, []byte("partial"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := NewCachedEmbedder(NewSimpleEmbedder(16), dir, 0)
+	if err != nil {
+		t.Fatalf("NewCachedEmbedder() error = %v", err)
+	}
+	for _, name := range unrelated {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("%s was removed: %v", name, err)
+		}
+	}
+	if _, err := os.Stat(leftover); !os.IsNotExist(err) {
+		t.Errorf("temporary file in shard directory was not removed")
+	}
+	if len(c.entries) != 0 {
+		t.Errorf("entries = %d, want 0", len(c.entries))
+	}
+}
+
+func TestCachedEmbedderHitsAfterReopen(t *testing.T) {
+	dir := t.TempDir()
+	inner := NewSimpleEmbedder(16)
+	c, err := NewCachedEmbedder(inner, dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := c.EmbedBatch([]string{"退款流程", "refund policy"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := NewCachedEmbedder(inner, dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := reopened.EmbedBatch([]string{"退款流程", "refund policy"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range want {
+		if !slices.Equal(got[i], want[i]) {
+			t.Errorf("vector %d differs after reopening the cache", i)
+		}
+	}
+	if hits, misses := reopened.Stats(); hits != 2 || misses != 0 {
+		t.Errorf("Stats() = %d hits, %d misses, want 2, 0", hits, misses)
+	}
+}
+
+// versionedEmbedder 可以修改指纹版本和维度的嵌入器
+type versionedEmbedder struct {
+	*SimpleEmbedder
+	version string
+	down    bool
+	calls   int
+}
+
+func (e *versionedEmbedder) Embed(text string) ([]float32, error) {
+	e.calls++
+	return e.SimpleEmbedder.Embed(text)
+}
+
+func (e *versionedEmbedder) Fingerprint() Fingerprint {
+	fp := e.SimpleEmbedder.Fingerprint()
+	fp.Version = e.version
+	if e.down {
+		fp.Dimension = 0
+	}
+	return fp
+}
+
+func TestCachedEmbedderKeyIncludesFingerprint(t *testing.T) {
+	dir := t.TempDir()
+	v1 := &versionedEmbedder{SimpleEmbedder: NewSimpleEmbedder(16), version: "1"}
+	c, err := NewCachedEmbedder(v1, dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Embed("退款"); err != nil {
+		t.Fatal(err)
+	}
+
+	v2 := &versionedEmbedder{SimpleEmbedder: NewSimpleEmbedder(16), version: "2"}
+	c2, err := NewCachedEmbedder(v2, dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c2.Embed("退款"); err != nil {
+		t.Fatal(err)
+	}
+	if v2.calls != 1 {
+		t.Errorf("vector from fingerprint version 1 was served to version 2")
+	}
+}
+
+func TestCachedEmbedderResolvesDimensionLazily(t *testing.T) {
+	inner := &versionedEmbedder{SimpleEmbedder: NewSimpleEmbedder(16), version: "1", down: true}
+	c, err := NewCachedEmbedder(inner, t.TempDir(), 0)
+	if err != nil {
+		t.Fatalf("NewCachedEmbedder() with unknown dimension error = %v", err)
+	}
+	if _, err := c.Embed("退款"); err != nil {
+		t.Fatal(err)
+	}
+	if hits, misses := c.Stats(); hits+misses != 0 {
+		t.Errorf("cache used before dimension was known")
+	}
+
+	inner.down = false
+	for range 2 {
+		if _, err := c.Embed("退款"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if hits, misses := c.Stats(); hits != 1 || misses != 1 {
+		t.Errorf("Stats() = %d hits, %d misses, want 1, 1", hits, misses)
+	}
+}
+
+func TestCachedEmbedderEvictsToLowWater(t *testing.T) {
+	dir := t.TempDir()
+	//每条向量 16*4 字节，上限放得下 10 条
+	c, err := NewCachedEmbedder(NewSimpleEmbedder(16), dir, 10*64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range 11 {
+		if _, err := c.Embed(string(rune('a' + i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if want := int64(9 * 64); c.size > want {
+		t.Errorf("size after eviction = %d, want <= %d", c.size, want)
+	}
+	var files int
+	filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			files++
+		}
+		return nil
+	})
+	if files != len(c.entries) {
+		t.Errorf("%d cache files on disk, %d entries", files, len(c.entries))
+	}
+}
@@ -12,6 +12,12 @@ type Embedder interface {
 	Dimension() int
 }
 
+// BatchEmbedder 支持批量生成嵌入的嵌入器，一次请求处理多条文本，结果顺序与输入一致
+type BatchEmbedder interface {
+	Embedder
+	EmbedBatch(texts []string) ([][]float32, error)
+}
+
 // SimpleEmbedder 简单的嵌入器（基于TF-IDF和n-gram）
 type SimpleEmbedder struct {
 	dimension int
@@ -60,8 +66,21 @@ func (e *SimpleEmbedder) Dimension() int {
 	return e.dimension
 }
 
-// BatchEmbed 批量生成嵌入
+// ID 返回嵌入器标识
+func (e *SimpleEmbedder) ID() string {
+	return "simple-ngram"
+}
+
+// Fingerprint 返回嵌入器指纹，修改特征提取方式时需要升级版本号
+func (e *SimpleEmbedder) Fingerprint() Fingerprint {
+	return Fingerprint{Name: e.ID(), Model: "char-1-3gram-fnv32a", Dimension: e.dimension, Normalized: true, Version: "1"}
+}
+
+// BatchEmbed 批量生成嵌入，嵌入器支持批量请求时一次交给它处理
 func BatchEmbed(embedder Embedder, texts []string) ([][]float32, error) {
+	if batch, ok := embedder.(BatchEmbedder); ok {
+		return batch.EmbedBatch(texts)
+	}
 	vectors := make([][]float32, len(texts))
 	for i, text := range texts {
 		vector, err := embedder.Embed(text)
@@ -0,0 +1,41 @@
+package vector
+
+import "fmt"
+
+// Fingerprint 嵌入器指纹，写入向量存储文件，用于判断存储中的向量是否由当前嵌入器生成
+type Fingerprint struct {
+	Name       string `json:"name"`
+	Model      string `json:"model,omitempty"`
+	Dimension  int    `json:"dimension"`
+	Normalized bool   `json:"normalized"`
+	Version    string `json:"version,omitempty"`
+}
+
+// Fingerprinter 能提供指纹的嵌入器
+type Fingerprinter interface {
+	Fingerprint() Fingerprint
+}
+
+// FingerprintOf 返回嵌入器的指纹，未实现 Fingerprinter 时用标识和维度代替
+func FingerprintOf(e Embedder) Fingerprint {
+	if f, ok := e.(Fingerprinter); ok {
+		return f.Fingerprint()
+	}
+	return Fingerprint{Name: Identity(e), Dimension: e.Dimension()}
+}
+
+// String 返回便于阅读的指纹描述
+func (f Fingerprint) String() string {
+	s := f.Name
+	if f.Model != "" {
+		s += "/" + f.Model
+	}
+	s += fmt.Sprintf(" dim=%d", f.Dimension)
+	if f.Normalized {
+		s += " normalized"
+	}
+	if f.Version != "" {
+		s += " v" + f.Version
+	}
+	return s
+}
@@ -0,0 +1,130 @@
+package vector
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultOllamaBatchSize 每次请求 /api/embed 的默认文本数
+const DefaultOllamaBatchSize = 32
+
+// OllamaEmbedder 调用 Ollama /api/embed 接口的嵌入器，支持 nomic-embed-text、bge-m3 等嵌入模型
+type OllamaEmbedder struct {
+	BaseURL    string
+	Model      string
+	BatchSize  int
+	HTTPClient *http.Client
+
+	dimMu     sync.Mutex
+	dimension int
+}
+
+// ollamaEmbedRequest /api/embed 请求
+type ollamaEmbedRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+// ollamaEmbedResponse /api/embed 响应
+type ollamaEmbedResponse struct {
+	Embeddings [][]float32 `json:"embeddings"`
+	Error      string      `json:"error,omitempty"`
+}
+
+// NewOllamaEmbedder 创建 Ollama 嵌入器
+func NewOllamaEmbedder(baseURL, model string, batchSize int) *OllamaEmbedder {
+	if batchSize <= 0 {
+		batchSize = DefaultOllamaBatchSize
+	}
+	return &OllamaEmbedder{
+		BaseURL:    baseURL,
+		Model:      model,
+		BatchSize:  batchSize,
+		HTTPClient: &http.Client{Timeout: 60 * time.Second},
+	}
+}
+
+// Embed 生成嵌入向量
+func (e *OllamaEmbedder) Embed(text string) ([]float32, error) {
+	vectors, err := e.EmbedBatch([]string{text})
+	if err != nil {
+		return nil, err
+	}
+	return vectors[0], nil
+}
+
+// EmbedBatch 批量生成嵌入向量，按 BatchSize 分批请求，结果顺序与输入一致
+func (e *OllamaEmbedder) EmbedBatch(texts []string) ([][]float32, error) {
+	vectors := make([][]float32, 0, len(texts))
+	for start := 0; start < len(texts); start += e.BatchSize {
+		end := min(start+e.BatchSize, len(texts))
+		batch, err := e.request(texts[start:end])
+		if err != nil {
+			return nil, err
+		}
+		vectors = append(vectors, batch...)
+	}
+	return vectors, nil
+}
+
+// request 发送一次 /api/embed 请求
+func (e *OllamaEmbedder) request(texts []string) ([][]float32, error) {
+	jsonData, err := json.Marshal(ollamaEmbedRequest{Model: e.Model, Input: texts})
+	if err != nil {
+		return nil, fmt.Errorf("序列化请求失败：%v", err)
+	}
+	url := fmt.Sprintf("%s/api/embed", e.BaseURL)
+	resp, err := e.HTTPClient.Post(url, "application/json", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("嵌入API请求失败：%v", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("读取响应失败：%v", err)
+	}
+	var response ollamaEmbedResponse
+	if resp.StatusCode != http.StatusOK {
+		//错误响应不一定是JSON，如旧版本 Ollama 没有 /api/embed 时返回纯文本的 404
+		message := strings.TrimSpace(string(body))
+		if json.Unmarshal(body, &response) == nil && response.Error != "" {
+			message = response.Error
+		}
+		return nil, fmt.Errorf("嵌入API返回错误 %d：%s", resp.StatusCode, message)
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("解析响应失败：%v", err)
+	}
+	if len(response.Embeddings) != len(texts) {
+		return nil, fmt.Errorf("嵌入数量不匹配：请求 %d 条，返回 %d 条", len(texts), len(response.Embeddings))
+	}
+	return response.Embeddings, nil
+}
+
+// ID 返回嵌入器标识
+func (e *OllamaEmbedder) ID() string {
+	return "ollama:" + e.Model
+}
+
+// Fingerprint 返回嵌入器指纹，/api/embed 返回的向量已归一化
+func (e *OllamaEmbedder) Fingerprint() Fingerprint {
+	return Fingerprint{Name: "ollama", Model: e.Model, Dimension: e.Dimension(), Normalized: true, Version: "1"}
+}
+
+// Dimension 返回向量维度，请求一次接口获取后缓存；失败时返回 0，下次调用重试
+func (e *OllamaEmbedder) Dimension() int {
+	e.dimMu.Lock()
+	defer e.dimMu.Unlock()
+	if e.dimension == 0 {
+		if vector, err := e.Embed("dimension"); err == nil {
+			e.dimension = len(vector)
+		}
+	}
+	return e.dimension
+}
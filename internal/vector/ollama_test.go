@@ -0,0 +1,153 @@
+package vector
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeOllama 模拟 /api/embed：每条文本的向量为 [文本长度, 批次序号]，记录每次请求的文本数
+type fakeOllama struct {
+	mu      sync.Mutex
+	batches []int
+	handler func(w http.ResponseWriter, req ollamaEmbedRequest) bool
+}
+
+func (f *fakeOllama) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/api/embed" {
+		http.Error(w, "404 page not found", http.StatusNotFound)
+		return
+	}
+	var req ollamaEmbedRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	f.mu.Lock()
+	batch := len(f.batches)
+	f.batches = append(f.batches, len(req.Input))
+	f.mu.Unlock()
+	if f.handler != nil && f.handler(w, req) {
+		return
+	}
+	var resp ollamaEmbedResponse
+	for _, text := range req.Input {
+		resp.Embeddings = append(resp.Embeddings, []float32{float32(len(text)), float32(batch)})
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+func TestOllamaEmbedBatch(t *testing.T) {
+	fake := &fakeOllama{}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	e := NewOllamaEmbedder(server.URL, "nomic-embed-text", 2)
+	texts := []string{"a", "bb", "ccc", "dddd", "eeeee"}
+	vectors, err := e.EmbedBatch(texts)
+	if err != nil {
+		t.Fatalf("EmbedBatch() error = %v", err)
+	}
+	if want := []int{2, 2, 1}; !slices.Equal(fake.batches, want) {
+		t.Errorf("batch sizes = %v, want %v", fake.batches, want)
+	}
+	if len(vectors) != len(texts) {
+		t.Fatalf("len(vectors) = %d, want %d", len(vectors), len(texts))
+	}
+	for i, text := range texts {
+		if got := int(vectors[i][0]); got != len(text) {
+			t.Errorf("vectors[%d] belongs to text of length %d, want %d", i, got, len(text))
+		}
+		if got, want := int(vectors[i][1]), i/2; got != want {
+			t.Errorf("vectors[%d] from batch %d, want %d", i, got, want)
+		}
+	}
+}
+
+func TestOllamaEmbedErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		handler func(w http.ResponseWriter, req ollamaEmbedRequest) bool
+		want    string
+	}{
+		{
+			name: "数量不匹配",
+			handler: func(w http.ResponseWriter, req ollamaEmbedRequest) bool {
+				json.NewEncoder(w).Encode(ollamaEmbedResponse{Embeddings: [][]float32{{1, 2}}})
+				return true
+			},
+			want: "嵌入数量不匹配：请求 2 条，返回 1 条",
+		},
+		{
+			name: "JSON错误响应",
+			handler: func(w http.ResponseWriter, req ollamaEmbedRequest) bool {
+				w.WriteHeader(http.StatusNotFound)
+				json.NewEncoder(w).Encode(ollamaEmbedResponse{Error: `model "x" not found`})
+				return true
+			},
+			want: `嵌入API返回错误 404：model "x" not found`,
+		},
+		{
+			name: "纯文本错误响应",
+			path: "/old",
+			want: "嵌入API返回错误 404：404 page not found",
+		},
+		{
+			name: "服务端错误",
+			handler: func(w http.ResponseWriter, req ollamaEmbedRequest) bool {
+				http.Error(w, "internal error", http.StatusInternalServerError)
+				return true
+			},
+			want: "嵌入API返回错误 500：internal error",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(&fakeOllama{handler: tt.handler})
+			defer server.Close()
+
+			e := NewOllamaEmbedder(server.URL+tt.path, "nomic-embed-text", 8)
+			_, err := e.EmbedBatch([]string{"a", "b"})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("EmbedBatch() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestOllamaDimensionRetriesAfterFailure(t *testing.T) {
+	var mu sync.Mutex
+	down := true
+	fake := &fakeOllama{handler: func(w http.ResponseWriter, req ollamaEmbedRequest) bool {
+		mu.Lock()
+		defer mu.Unlock()
+		if down {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return true
+		}
+		return false
+	}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	e := NewOllamaEmbedder(server.URL, "nomic-embed-text", 8)
+	if got := e.Dimension(); got != 0 {
+		t.Fatalf("Dimension() while down = %d, want 0", got)
+	}
+	mu.Lock()
+	down = false
+	mu.Unlock()
+	if got := e.Dimension(); got != 2 {
+		t.Fatalf("Dimension() after recovery = %d, want 2", got)
+	}
+	requests := len(fake.batches)
+	e.Dimension()
+	if len(fake.batches) != requests {
+		t.Errorf("Dimension() requested again after success")
+	}
+}